@@ -0,0 +1,98 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"tickets/domain/booking"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	bookingProcessKeyPrefix = "booking-process:"
+	bookingProcessTTL       = 7 * 24 * time.Hour
+)
+
+type BookingProcessRepository interface {
+	Get(ctx context.Context, ticketID string) (*booking.Process, error)
+	// Update loads the process of the ticket, starting a new one if it does not exist yet,
+	// and stores it after applying updateFn.
+	Update(ctx context.Context, ticketID string, updateFn func(process *booking.Process) error) error
+}
+
+// BookingProcessRedisRepository persists the booking processes in Redis.
+type BookingProcessRedisRepository struct {
+	rdb       *redis.Client
+	clock     Clock
+	keyPrefix string
+}
+
+// NewBookingProcessRedisRepository stores the processes under keys prefixed with keyPrefix,
+// so the services of different namespaces don't share them.
+func NewBookingProcessRedisRepository(rdb *redis.Client, clock Clock, keyPrefix string) BookingProcessRedisRepository {
+	return BookingProcessRedisRepository{
+		rdb:       rdb,
+		clock:     clock,
+		keyPrefix: keyPrefix + bookingProcessKeyPrefix,
+	}
+}
+
+func (r BookingProcessRedisRepository) Get(ctx context.Context, ticketID string) (*booking.Process, error) {
+	data, err := r.rdb.Get(ctx, r.keyPrefix+ticketID).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get booking process %s: %w", ticketID, err)
+	}
+
+	var process booking.Process
+	err = json.Unmarshal(data, &process)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal booking process %s: %w", ticketID, err)
+	}
+
+	return &process, nil
+}
+
+// Update stores the process after applying updateFn. Concurrent updates of the same
+// process make Update to fail, so the message that caused it is retried.
+func (r BookingProcessRedisRepository) Update(
+	ctx context.Context,
+	ticketID string,
+	updateFn func(process *booking.Process) error,
+) error {
+	key := r.keyPrefix + ticketID
+
+	return r.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		process := booking.NewProcess(ticketID, r.clock.Now())
+
+		data, err := tx.Get(ctx, key).Bytes()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			return err
+		default:
+			err = json.Unmarshal(data, process)
+			if err != nil {
+				return fmt.Errorf("unable to unmarshal booking process %s: %w", ticketID, err)
+			}
+		}
+
+		err = updateFn(process)
+		if err != nil {
+			return err
+		}
+
+		data, err = json.Marshal(process)
+		if err != nil {
+			return fmt.Errorf("unable to marshal booking process %s: %w", ticketID, err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, bookingProcessTTL)
+			return nil
+		})
+		return err
+	}, key)
+}
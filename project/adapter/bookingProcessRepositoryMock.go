@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tickets/domain/booking"
+)
+
+type BookingProcessRepositoryMock struct {
+	// Clock sets the start of the new processes. It must be set before the mock is used.
+	Clock Clock
+
+	mock      sync.Mutex
+	Processes map[string]booking.Process
+}
+
+func NewBookingProcessRepositoryMock() *BookingProcessRepositoryMock {
+	return &BookingProcessRepositoryMock{
+		Clock:     SystemClock{},
+		mock:      sync.Mutex{},
+		Processes: map[string]booking.Process{},
+	}
+}
+
+func (r *BookingProcessRepositoryMock) Get(ctx context.Context, ticketID string) (*booking.Process, error) {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	process, ok := r.Processes[ticketID]
+	if !ok {
+		return nil, fmt.Errorf("booking process %s not found", ticketID)
+	}
+
+	return &process, nil
+}
+
+func (r *BookingProcessRepositoryMock) Update(
+	ctx context.Context,
+	ticketID string,
+	updateFn func(process *booking.Process) error,
+) error {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	process, ok := r.Processes[ticketID]
+	if !ok {
+		process = *booking.NewProcess(ticketID, r.Clock.Now())
+	}
+
+	err := updateFn(&process)
+	if err != nil {
+		return err
+	}
+
+	r.Processes[ticketID] = process
+	return nil
+}
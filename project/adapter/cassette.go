@@ -0,0 +1,305 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+)
+
+type CassetteMode string
+
+const (
+	// CassetteRecord calls the decorated service and appends every call to the cassette file.
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers the calls with the recorded ones, without calling any service.
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrCassetteMiss is returned in replay mode when no recorded call matches.
+var ErrCassetteMiss = errors.New("no recorded call matches")
+
+// Interaction is a call recorded in a cassette, one JSON object per line.
+type Interaction struct {
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+	// Key identifies what the call is about, like the ticket ID, since the requests
+	// carry values, like idempotency keys, that change when a scenario is reproduced.
+	Key           string          `json:"key"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Request       json.RawMessage `json:"request"`
+	Response      json.RawMessage `json:"response,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	// StatusCode is set when the call failed with a StatusError.
+	StatusCode int       `json:"status_code,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Cassette records the calls to the external services in a file, and replays them,
+// so a failing scenario can be reproduced offline.
+type Cassette struct {
+	mode  CassetteMode
+	clock Clock
+
+	mu           sync.Mutex
+	file         *os.File
+	interactions []Interaction
+}
+
+// OpenCassette opens the file to append the calls to it, or reads the recorded calls to replay them.
+func OpenCassette(path string, mode CassetteMode, clock Clock) (*Cassette, error) {
+	cassette := &Cassette{
+		mode:  mode,
+		clock: clock,
+	}
+
+	switch mode {
+	case CassetteRecord:
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open cassette %s: %w", path, err)
+		}
+		cassette.file = file
+	case CassetteReplay:
+		interactions, err := readInteractions(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read cassette %s: %w", path, err)
+		}
+		cassette.interactions = interactions
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	return cassette, nil
+}
+
+func readInteractions(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	decoder := json.NewDecoder(file)
+	for {
+		var interaction Interaction
+		err := decoder.Decode(&interaction)
+		if errors.Is(err, io.EOF) {
+			return interactions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, interaction)
+	}
+}
+
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Remaining returns the recorded calls that were not replayed yet.
+func (c *Cassette) Remaining() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.interactions)
+}
+
+// Close flushes the recorded calls to the disk. It must be called once nothing is recorded anymore.
+func (c *Cassette) Close() error {
+	if c.file == nil {
+		return nil
+	}
+
+	return errors.Join(c.file.Sync(), c.file.Close())
+}
+
+// play records the call made by call, or replays a recorded one into response.
+func (c *Cassette) play(
+	ctx context.Context,
+	interaction Interaction,
+	request any,
+	response any,
+	call func() error,
+) error {
+	var err error
+	interaction.CorrelationID = log.CorrelationIDFromContext(ctx)
+	interaction.Request, err = json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("unable to marshal %s request: %w", interaction.Operation, err)
+	}
+
+	if c.mode == CassetteReplay {
+		return c.replay(interaction, response)
+	}
+
+	callErr := call()
+
+	interaction.RecordedAt = c.clock.Now()
+	if callErr != nil {
+		interaction.Error = callErr.Error()
+		var statusErr StatusError
+		if errors.As(callErr, &statusErr) {
+			interaction.StatusCode = statusErr.StatusCode
+		}
+	} else if response != nil {
+		interaction.Response, err = json.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("unable to marshal %s response: %w", interaction.Operation, err)
+		}
+	}
+
+	err = c.record(interaction)
+	if err != nil {
+		// The call was made, so failing it would only make it to be retried.
+		log.FromContext(ctx).WithError(err).Error("Unable to record call in cassette")
+	}
+
+	return callErr
+}
+
+func (c *Cassette) record(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// replay takes the first recorded call matching the service, operation and key,
+// preferring the ones with the same correlation ID, so retries get the recorded outcomes in order.
+func (c *Cassette) replay(interaction Interaction, response any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matches := func(recorded Interaction) bool {
+		return recorded.Service == interaction.Service &&
+			recorded.Operation == interaction.Operation &&
+			recorded.Key == interaction.Key
+	}
+
+	index := slices.IndexFunc(c.interactions, func(recorded Interaction) bool {
+		return matches(recorded) && recorded.CorrelationID == interaction.CorrelationID
+	})
+	if index == -1 {
+		index = slices.IndexFunc(c.interactions, matches)
+	}
+	if index == -1 {
+		return fmt.Errorf("%w: %s %s %s", ErrCassetteMiss, interaction.Service, interaction.Operation, interaction.Key)
+	}
+
+	recorded := c.interactions[index]
+	c.interactions = slices.Delete(c.interactions, index, index+1)
+
+	switch {
+	case recorded.StatusCode != 0:
+		return StatusError{StatusCode: recorded.StatusCode}
+	case recorded.Error != "":
+		return errors.New(recorded.Error)
+	case response != nil && len(recorded.Response) > 0:
+		return json.Unmarshal(recorded.Response, response)
+	default:
+		return nil
+	}
+}
+
+// CassetteReceiptsService records or replays the calls to a ReceiptsService.
+// In replay mode, the decorated service can be nil.
+type CassetteReceiptsService struct {
+	next     ReceiptsService
+	cassette *Cassette
+}
+
+func NewCassetteReceiptsService(next ReceiptsService, cassette *Cassette) CassetteReceiptsService {
+	return CassetteReceiptsService{
+		next:     next,
+		cassette: cassette,
+	}
+}
+
+func (s CassetteReceiptsService) IssueReceipt(ctx context.Context, request IssueReceiptRequest) (IssueReceiptResponse, error) {
+	var response IssueReceiptResponse
+	err := s.cassette.play(
+		ctx,
+		Interaction{Service: "receipts", Operation: "IssueReceipt", Key: request.TicketID},
+		request,
+		&response,
+		func() error {
+			var err error
+			response, err = s.next.IssueReceipt(ctx, request)
+			return err
+		},
+	)
+
+	return response, err
+}
+
+func (s CassetteReceiptsService) VoidReceipt(ctx context.Context, request VoidReceiptRequest) error {
+	return s.cassette.play(
+		ctx,
+		Interaction{Service: "receipts", Operation: "VoidReceipt", Key: request.TicketID},
+		request,
+		nil,
+		func() error {
+			return s.next.VoidReceipt(ctx, request)
+		},
+	)
+}
+
+// CassetteSpreadsheetsAPI records or replays the calls to a SpreadsheetsAPI.
+// In replay mode, the decorated API can be nil.
+type CassetteSpreadsheetsAPI struct {
+	next     SpreadsheetsAPI
+	cassette *Cassette
+}
+
+func NewCassetteSpreadsheetsAPI(next SpreadsheetsAPI, cassette *Cassette) CassetteSpreadsheetsAPI {
+	return CassetteSpreadsheetsAPI{
+		next:     next,
+		cassette: cassette,
+	}
+}
+
+type appendRowsRequest struct {
+	SheetName string     `json:"sheet_name"`
+	Rows      [][]string `json:"rows"`
+}
+
+func (s CassetteSpreadsheetsAPI) AppendRow(ctx context.Context, sheetName string, row []string) error {
+	return s.cassette.play(
+		ctx,
+		Interaction{Service: "spreadsheets", Operation: "AppendRow", Key: rowsKey(sheetName, [][]string{row})},
+		appendRowsRequest{SheetName: sheetName, Rows: [][]string{row}},
+		nil,
+		func() error {
+			return s.next.AppendRow(ctx, sheetName, row)
+		},
+	)
+}
+
+// rowsKey is made of the sheet and the first cell of each row, which is the ticket ID.
+func rowsKey(sheetName string, rows [][]string) string {
+	firstCells := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 0 {
+			firstCells = append(firstCells, row[0])
+		}
+	}
+
+	return sheetName + ":" + strings.Join(firstCells, ",")
+}
@@ -0,0 +1,88 @@
+package adapter_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	clock := adapter.NewClockMock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	ctx := log.ContextWithCorrelationID(context.Background(), "correlation-1")
+
+	mocks := adapter.NewClientsMock()
+	mocks.Receipts.Clock = clock
+	mocks.Receipts.FailFor("ticket-1", nil)
+
+	recording, err := adapter.OpenCassette(path, adapter.CassetteRecord, clock)
+	require.NoError(t, err)
+	receipts := adapter.NewCassetteReceiptsService(mocks.Receipts, recording)
+	spreadsheets := adapter.NewCassetteSpreadsheetsAPI(mocks.Spreadsheets, recording)
+
+	_, err = receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-1", IdempotencyKey: "key-1"})
+	require.Error(t, err)
+	mocks.Receipts.Recover("ticket-1")
+	issued, err := receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-1", IdempotencyKey: "key-1"})
+	require.NoError(t, err)
+	require.NoError(t, spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"ticket-1", "50.00"}))
+	require.NoError(t, recording.Close())
+
+	replaying, err := adapter.OpenCassette(path, adapter.CassetteReplay, clock)
+	require.NoError(t, err)
+	assert.Len(t, replaying.Remaining(), 3)
+	receipts = adapter.NewCassetteReceiptsService(nil, replaying)
+	spreadsheets = adapter.NewCassetteSpreadsheetsAPI(nil, replaying)
+
+	// The idempotency key changes when the scenario is reproduced, so it is not matched.
+	replayCtx := log.ContextWithCorrelationID(context.Background(), "correlation-2")
+	_, err = receipts.IssueReceipt(replayCtx, adapter.IssueReceiptRequest{TicketID: "ticket-1", IdempotencyKey: "key-2"})
+	assert.Equal(t, adapter.StatusError{StatusCode: http.StatusInternalServerError}, err)
+	replayed, err := receipts.IssueReceipt(replayCtx, adapter.IssueReceiptRequest{TicketID: "ticket-1", IdempotencyKey: "key-2"})
+	require.NoError(t, err)
+	assert.Equal(t, issued.ReceiptNumber, replayed.ReceiptNumber)
+	assert.True(t, issued.IssuedAt.Equal(replayed.IssuedAt))
+	require.NoError(t, spreadsheets.AppendRow(replayCtx, "tickets-to-print", []string{"ticket-1", "50.00"}))
+
+	assert.Empty(t, replaying.Remaining())
+	_, err = receipts.IssueReceipt(replayCtx, adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	assert.ErrorIs(t, err, adapter.ErrCassetteMiss)
+
+	assert.Len(t, mocks.Receipts.Issued(), 1)
+	assert.Len(t, mocks.Spreadsheets.RowsFor("tickets-to-print"), 1)
+}
+
+func TestCassette_prefersSameCorrelationID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	clock := adapter.NewClockMock(time.Now())
+	mocks := adapter.NewClientsMock()
+
+	recording, err := adapter.OpenCassette(path, adapter.CassetteRecord, clock)
+	require.NoError(t, err)
+	receipts := adapter.NewCassetteReceiptsService(mocks.Receipts, recording)
+
+	first, err := receipts.IssueReceipt(log.ContextWithCorrelationID(context.Background(), "first"), adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+	second, err := receipts.IssueReceipt(log.ContextWithCorrelationID(context.Background(), "second"), adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+	require.NoError(t, recording.Close())
+
+	replaying, err := adapter.OpenCassette(path, adapter.CassetteReplay, clock)
+	require.NoError(t, err)
+	receipts = adapter.NewCassetteReceiptsService(nil, replaying)
+
+	replayed, err := receipts.IssueReceipt(log.ContextWithCorrelationID(context.Background(), "second"), adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+	assert.Equal(t, second.ReceiptNumber, replayed.ReceiptNumber)
+
+	replayed, err = receipts.IssueReceipt(log.ContextWithCorrelationID(context.Background(), "other"), adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+	assert.Equal(t, first.ReceiptNumber, replayed.ReceiptNumber)
+}
@@ -1,32 +1,125 @@
 package adapter
 
 import (
-	"context"
-	"net/http"
+	"cmp"
+	"fmt"
+	"net/url"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
-	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/spreadsheets"
 )
 
 type Clients struct {
 	Receipts     ReceiptsService
 	Spreadsheets SpreadsheetsAPI
+	Payments     PaymentsService
+	Files        FileStorage
+	Notifier     Notifier
+}
+
+// ClientsConfig configures the HTTP clients of each service called through the gateway,
+// the file storage and the notifier.
+type ClientsConfig struct {
+	GatewayAddr string
+
+	// ReceiptsAddr, SpreadsheetsAddr and PaymentsAddr are the base URLs of each service,
+	// used instead of the service path in the gateway when set.
+	ReceiptsAddr     string
+	SpreadsheetsAddr string
+	PaymentsAddr     string
+
+	Receipts     HTTPClientConfig
+	Spreadsheets HTTPClientConfig
+	Payments     HTTPClientConfig
+
+	// FileStorageDir is the directory where the ticket files are stored.
+	FileStorageDir string
+	SMTP           SMTPConfig
+	// Clock sets the date of the emails. It defaults to the system clock.
+	Clock Clock
+}
+
+func DefaultClientsConfig(addr string) ClientsConfig {
+	return ClientsConfig{
+		GatewayAddr:  addr,
+		Receipts:     DefaultHTTPClientConfig(),
+		Spreadsheets: DefaultHTTPClientConfig(),
+		Payments:     DefaultHTTPClientConfig(),
+
+		FileStorageDir: defaultFileStorageDir,
+		SMTP:           DefaultSMTPConfig(),
+		Clock:          SystemClock{},
+	}
 }
 
 func NewClients(addr string) Clients {
-	clients, err := clients.NewClients(
-		addr,
-		func(ctx context.Context, req *http.Request) error {
-			req.Header.Set("Correlation-ID", log.CorrelationIDFromContext(ctx))
-			return nil
-		},
+	return NewClientsWithConfig(DefaultClientsConfig(addr))
+}
+
+func NewClientsWithConfig(config ClientsConfig) Clients {
+	receiptsClient, err := receipts.NewClientWithResponses(
+		config.serviceURL(config.ReceiptsAddr, "receipts-api"),
+		receipts.WithRequestEditorFn(correlationIDEditor),
+		receipts.WithHTTPClient(newHTTPClient("receipts", config.Receipts)),
+	)
+	if err != nil {
+		panic(fmt.Errorf("failed to create receipts client: %w", err))
+	}
+
+	spreadsheetsClient, err := spreadsheets.NewClientWithResponses(
+		config.serviceURL(config.SpreadsheetsAddr, "spreadsheets-api"),
+		spreadsheets.WithRequestEditorFn(correlationIDEditor),
+		spreadsheets.WithHTTPClient(newHTTPClient("spreadsheets", config.Spreadsheets)),
+	)
+	if err != nil {
+		panic(fmt.Errorf("failed to create spreadsheets client: %w", err))
+	}
+
+	paymentsClient, err := payments.NewClientWithResponses(
+		config.serviceURL(config.PaymentsAddr, "payments-api"),
+		payments.WithRequestEditorFn(correlationIDEditor),
+		payments.WithHTTPClient(newHTTPClient("payments", config.Payments)),
 	)
 	if err != nil {
-		panic(err)
+		panic(fmt.Errorf("failed to create payments client: %w", err))
+	}
+
+	clients := &clients.Clients{
+		Receipts:     receiptsClient,
+		Spreadsheets: spreadsheetsClient,
+		Payments:     paymentsClient,
+	}
+
+	var clock Clock = SystemClock{}
+	if config.Clock != nil {
+		clock = config.Clock
 	}
 
 	return Clients{
 		Receipts:     NewReceiptsClient(clients),
 		Spreadsheets: NewSpreadsheetsClient(clients),
+		Payments:     NewPaymentsClient(clients),
+		Files:        NewLocalFileStorage(cmp.Or(config.FileStorageDir, defaultFileStorageDir)),
+		Notifier:     NewSMTPNotifier(config.SMTP, clock),
+	}
+}
+
+// serviceURL returns the base URL of the service, falling back to its path in the gateway.
+func (c ClientsConfig) serviceURL(addr string, service string) string {
+	if addr != "" {
+		return addr
+	}
+
+	if c.GatewayAddr == "" {
+		panic(fmt.Errorf("gateway address is required to reach %s", service))
 	}
+
+	serviceURL, err := url.JoinPath(c.GatewayAddr, service)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s address %q: %w", service, c.GatewayAddr, err))
+	}
+
+	return serviceURL
 }
@@ -3,11 +3,27 @@ package adapter
 type ClientMocks struct {
 	Receipts     *ReceiptsServiceMock
 	Spreadsheets *SpreadsheetsAPIMock
+	Payments     *PaymentsServiceMock
+	Files        *FileStorageMock
+	Notifier     *NotifierMock
 }
 
 func NewClientsMock() ClientMocks {
 	return ClientMocks{
 		Receipts:     NewReceiptsServiceMock(),
 		Spreadsheets: NewSpreadsheetsAPIMock(),
+		Payments:     NewPaymentsServiceMock(),
+		Files:        NewFileStorageMock(),
+		Notifier:     NewNotifierMock(),
+	}
+}
+
+func (m ClientMocks) Clients() Clients {
+	return Clients{
+		Receipts:     m.Receipts,
+		Spreadsheets: m.Spreadsheets,
+		Payments:     m.Payments,
+		Files:        m.Files,
+		Notifier:     m.Notifier,
 	}
 }
@@ -0,0 +1,18 @@
+package adapter_test
+
+import (
+	"testing"
+	"tickets/adapter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClients(t *testing.T) {
+	clients := adapter.NewClients("http://localhost:8888")
+
+	assert.NotNil(t, clients.Receipts)
+	assert.NotNil(t, clients.Spreadsheets)
+	assert.NotNil(t, clients.Payments)
+	assert.NotNil(t, clients.Files)
+	assert.NotNil(t, clients.Notifier)
+}
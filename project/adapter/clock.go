@@ -0,0 +1,33 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/lithammer/shortuuid"
+)
+
+// Clock tells the current time. It is injected instead of calling time.Now,
+// so tests and replays can control the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// IDGenerator creates the receipt numbers, request IDs and message UUIDs.
+// The context is the one of the call or of the published message, so the IDs
+// can depend on the message being handled.
+type IDGenerator interface {
+	NewID(ctx context.Context) string
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+type ShortUUIDGenerator struct{}
+
+func (ShortUUIDGenerator) NewID(ctx context.Context) string {
+	return shortuuid.New()
+}
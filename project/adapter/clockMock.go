@@ -0,0 +1,77 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ClockMock is a clock that only moves when told to.
+type ClockMock struct {
+	mock sync.Mutex
+	now  time.Time
+}
+
+func NewClockMock(now time.Time) *ClockMock {
+	return &ClockMock{
+		mock: sync.Mutex{},
+		now:  now,
+	}
+}
+
+func (c *ClockMock) Now() time.Time {
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	return c.now
+}
+
+func (c *ClockMock) Set(now time.Time) {
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	c.now = now
+}
+
+func (c *ClockMock) Advance(d time.Duration) {
+	c.mock.Lock()
+	defer c.mock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// IDGeneratorMock generates sequential IDs, like "prefix-1", "prefix-2".
+type IDGeneratorMock struct {
+	// Seed, when it returns a non-empty seed for the context, numbers the IDs by that
+	// seed instead of the prefix, like "seed-1", "seed-2". It must be set before the
+	// mock is used.
+	Seed func(ctx context.Context) string
+
+	mock   sync.Mutex
+	prefix string
+	next   map[string]int
+}
+
+func NewIDGeneratorMock(prefix string) *IDGeneratorMock {
+	return &IDGeneratorMock{
+		mock:   sync.Mutex{},
+		prefix: prefix,
+		next:   map[string]int{},
+	}
+}
+
+func (g *IDGeneratorMock) NewID(ctx context.Context) string {
+	prefix := g.prefix
+	if g.Seed != nil {
+		if seed := g.Seed(ctx); seed != "" {
+			prefix = seed
+		}
+	}
+
+	g.mock.Lock()
+	defer g.mock.Unlock()
+
+	g.next[prefix]++
+	return fmt.Sprintf("%s-%d", prefix, g.next[prefix])
+}
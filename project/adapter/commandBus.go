@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+func NewCommandBus(pub message.Publisher, ids IDGenerator) (*cqrs.CommandBus, error) {
+	return cqrs.NewCommandBusWithConfig(
+		pub,
+		cqrs.CommandBusConfig{
+			GeneratePublishTopic: func(params cqrs.CommandBusGeneratePublishTopicParams) (string, error) {
+				return params.CommandName, nil
+			},
+			Marshaler: cqrs.JSONMarshaler{
+				// The UUID depends on the context of the message, so it is set on publish.
+				NewUUID:      func() string { return "" },
+				GenerateName: cqrs.StructName,
+			},
+			OnSend: func(params cqrs.CommandBusOnSendParams) error {
+				params.Message.UUID = ids.NewID(params.Message.Context())
+				params.Message.Metadata.Set(TypeMetadataKey, params.CommandName)
+				return nil
+			},
+		},
+	)
+}
+
+type IssueReceipt struct {
+	TicketID       string       `json:"ticket_id"`
+	Price          MoneyPayload `json:"price"`
+	IdempotencyKey string       `json:"idempotency_key"`
+}
+
+type VoidReceipt struct {
+	TicketID string `json:"ticket_id"`
+	Reason   string `json:"reason"`
+}
+
+type AppendTicketRow struct {
+	TicketID  string   `json:"ticket_id"`
+	SheetName string   `json:"sheet_name"`
+	Row       []string `json:"row"`
+	// EventType is the event that caused the row. Along with the sheet and
+	// the ticket, it identifies the row so it is only appended once.
+	EventType string `json:"event_type"`
+}
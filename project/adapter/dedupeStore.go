@@ -0,0 +1,68 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	dedupeKeyPrefix = "dedupe:"
+	dedupeClaimKey  = "claim:"
+	dedupeTTL       = 30 * 24 * time.Hour
+)
+
+// DedupeStore records the side effects already done, so they are not repeated on redeliveries.
+type DedupeStore interface {
+	Done(ctx context.Context, key string) (bool, error)
+	// MarkDone records the side effect as done, and releases its claim.
+	MarkDone(ctx context.Context, key string) error
+
+	// Claim reserves the key until ttl elapses or it is released, so concurrent deliveries
+	// don't do the same side effect. It returns false when the key is already claimed.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, key string) error
+}
+
+type RedisDedupeStore struct {
+	rdb       *redis.Client
+	clock     Clock
+	keyPrefix string
+}
+
+// NewRedisDedupeStore stores the keys prefixed with keyPrefix, so the services
+// of different namespaces don't share them.
+func NewRedisDedupeStore(rdb *redis.Client, clock Clock, keyPrefix string) RedisDedupeStore {
+	return RedisDedupeStore{
+		rdb:       rdb,
+		clock:     clock,
+		keyPrefix: keyPrefix + dedupeKeyPrefix,
+	}
+}
+
+func (s RedisDedupeStore) Done(ctx context.Context, key string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, s.keyPrefix+key).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s RedisDedupeStore) MarkDone(ctx context.Context, key string) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.keyPrefix+key, s.clock.Now().Format(time.RFC3339), dedupeTTL)
+		pipe.Del(ctx, s.keyPrefix+dedupeClaimKey+key)
+		return nil
+	})
+	return err
+}
+
+func (s RedisDedupeStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.rdb.SetNX(ctx, s.keyPrefix+dedupeClaimKey+key, s.clock.Now().Format(time.RFC3339), ttl).Result()
+}
+
+func (s RedisDedupeStore) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, s.keyPrefix+dedupeClaimKey+key).Err()
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DedupeStoreMock keeps the claims until they are released, whatever their ttl.
+type DedupeStoreMock struct {
+	mock   sync.Mutex
+	Keys   map[string]struct{}
+	Claims map[string]struct{}
+}
+
+func NewDedupeStoreMock() *DedupeStoreMock {
+	return &DedupeStoreMock{
+		mock:   sync.Mutex{},
+		Keys:   map[string]struct{}{},
+		Claims: map[string]struct{}{},
+	}
+}
+
+func (s *DedupeStoreMock) Done(ctx context.Context, key string) (bool, error) {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	_, ok := s.Keys[key]
+	return ok, nil
+}
+
+func (s *DedupeStoreMock) MarkDone(ctx context.Context, key string) error {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	s.Keys[key] = struct{}{}
+	delete(s.Claims, key)
+	return nil
+}
+
+func (s *DedupeStoreMock) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	if _, ok := s.Claims[key]; ok {
+		return false, nil
+	}
+
+	s.Claims[key] = struct{}{}
+	return true, nil
+}
+
+func (s *DedupeStoreMock) Release(ctx context.Context, key string) error {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	delete(s.Claims, key)
+	return nil
+}
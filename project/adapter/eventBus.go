@@ -1,15 +1,48 @@
 package adapter
 
 import (
+	"context"
+	"time"
+
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 	"github.com/ThreeDotsLabs/watermill/message"
 )
 
 const (
 	TypeMetadataKey = "type"
+	// DeliverAtMetadataKey holds the RFC 3339 time when the message must be delivered.
+	DeliverAtMetadataKey = "deliver_at"
+	// DelayMetadataKey holds the time, as a Go duration, to wait before delivering the message.
+	DelayMetadataKey = "delay"
+)
+
+type (
+	deliverAtCtxKey struct{}
+	delayCtxKey     struct{}
 )
 
-func NewEventBus(pub message.Publisher) (*cqrs.EventBus, error) {
+// ContextWithDeliverAt makes the events published with the returned context to be delivered at the given time.
+func ContextWithDeliverAt(ctx context.Context, deliverAt time.Time) context.Context {
+	return context.WithValue(ctx, deliverAtCtxKey{}, deliverAt)
+}
+
+// ContextWithDelay makes the events published with the returned context to be delivered after the given delay.
+// The delay is counted from the publication, by the clock of the delayed publisher decorator.
+func ContextWithDelay(ctx context.Context, delay time.Duration) context.Context {
+	return context.WithValue(ctx, delayCtxKey{}, delay)
+}
+
+func deliverAtFromContext(ctx context.Context) (time.Time, bool) {
+	deliverAt, ok := ctx.Value(deliverAtCtxKey{}).(time.Time)
+	return deliverAt, ok
+}
+
+func delayFromContext(ctx context.Context) (time.Duration, bool) {
+	delay, ok := ctx.Value(delayCtxKey{}).(time.Duration)
+	return delay, ok
+}
+
+func NewEventBus(pub message.Publisher, ids IDGenerator) (*cqrs.EventBus, error) {
 	return cqrs.NewEventBusWithConfig(
 		pub,
 		cqrs.EventBusConfig{
@@ -17,10 +50,18 @@ func NewEventBus(pub message.Publisher) (*cqrs.EventBus, error) {
 				return params.EventName, nil
 			},
 			Marshaler: cqrs.JSONMarshaler{
+				// The UUID depends on the context of the message, so it is set on publish.
+				NewUUID:      func() string { return "" },
 				GenerateName: cqrs.StructName,
 			},
 			OnPublish: func(params cqrs.OnEventSendParams) error {
+				params.Message.UUID = ids.NewID(params.Message.Context())
 				params.Message.Metadata.Set(TypeMetadataKey, params.EventName)
+				if deliverAt, ok := deliverAtFromContext(params.Message.Context()); ok {
+					params.Message.Metadata.Set(DeliverAtMetadataKey, deliverAt.UTC().Format(time.RFC3339Nano))
+				} else if delay, ok := delayFromContext(params.Message.Context()); ok {
+					params.Message.Metadata.Set(DelayMetadataKey, delay.String())
+				}
 				return nil
 			},
 		},
@@ -39,6 +80,50 @@ type TicketBookingConfirmed struct {
 	Price         MoneyPayload `json:"price"`
 }
 
+type TicketReceiptIssued struct {
+	TicketID      string    `json:"ticket_id"`
+	ReceiptNumber string    `json:"receipt_number"`
+	IssuedAt      time.Time `json:"issued_at"`
+}
+
+type TicketReceiptVoided struct {
+	TicketID string `json:"ticket_id"`
+}
+
+type TicketPrinted struct {
+	TicketID string `json:"ticket_id"`
+	// FileName is the FileStorage reference of the printable PDF ticket.
+	FileName string `json:"file_name"`
+	// HTMLFileName is the FileStorage reference of the HTML version of the ticket.
+	HTMLFileName string `json:"html_file_name"`
+}
+
+type TicketNotificationSent struct {
+	TicketID string `json:"ticket_id"`
+	// Notification is the kind of notification sent, like booking_confirmation or refund.
+	Notification string `json:"notification"`
+}
+
+type TicketRefunded struct {
+	TicketID        string `json:"ticket_id"`
+	RefundReference string `json:"refund_reference"`
+}
+
+// TicketBookingTimeoutElapsed is published with a delay when a booking starts,
+// to check if the booking got stuck.
+type TicketBookingTimeoutElapsed struct {
+	TicketID string `json:"ticket_id"`
+}
+
+// TicketBookingTimedOut is published when a booking did not finish its steps in time,
+// so it can be followed up. The booking can still complete if the missing steps arrive late.
+type TicketBookingTimedOut struct {
+	TicketID         string `json:"ticket_id"`
+	ReceiptIssued    bool   `json:"receipt_issued"`
+	TicketPrinted    bool   `json:"ticket_printed"`
+	NotificationSent bool   `json:"notification_sent"`
+}
+
 type MoneyPayload struct {
 	Amount   string `json:"amount"`
 	Currency string `json:"currency"`
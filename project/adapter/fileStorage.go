@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ErrFileNotFound = errors.New("file not found")
+
+type FileStorage interface {
+	// Save stores the file, replacing it if it already exists, and returns its reference.
+	Save(ctx context.Context, name string, content []byte) (string, error)
+	Load(ctx context.Context, name string) ([]byte, error)
+}
+
+const defaultFileStorageDir = "data/files"
+
+// LocalFileStorage stores the files in a directory of the local filesystem.
+type LocalFileStorage struct {
+	dir string
+}
+
+func NewLocalFileStorage(dir string) LocalFileStorage {
+	return LocalFileStorage{
+		dir: dir,
+	}
+}
+
+func (s LocalFileStorage) Save(ctx context.Context, name string, content []byte) (string, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(s.dir, 0o755)
+	if err != nil {
+		return "", fmt.Errorf("unable to create storage dir: %w", err)
+	}
+
+	// Writing to a temporary file first, so a file is never read half written.
+	tmp, err := os.CreateTemp(s.dir, "."+name+".*")
+	if err != nil {
+		return "", fmt.Errorf("unable to create file %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("unable to write file %s: %w", name, err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return "", fmt.Errorf("unable to write file %s: %w", name, err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", fmt.Errorf("unable to store file %s: %w", name, err)
+	}
+
+	return name, nil
+}
+
+func (s LocalFileStorage) Load(ctx context.Context, name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+
+	return content, err
+}
+
+func (s LocalFileStorage) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+
+	return filepath.Join(s.dir, name), nil
+}
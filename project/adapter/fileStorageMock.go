@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type FileStorageMock struct {
+	mock  sync.Mutex
+	Files map[string][]byte
+
+	updates mockUpdates
+}
+
+func NewFileStorageMock() *FileStorageMock {
+	return &FileStorageMock{
+		mock:  sync.Mutex{},
+		Files: map[string][]byte{},
+	}
+}
+
+func (s *FileStorageMock) Save(ctx context.Context, name string, content []byte) (string, error) {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	s.Files[name] = content
+	s.updates.notify()
+	return name, nil
+}
+
+// WaitForFile blocks until the file is saved or the context is done.
+func (s *FileStorageMock) WaitForFile(ctx context.Context, name string) ([]byte, error) {
+	return waitFor(ctx, &s.mock, &s.updates, func() ([]byte, bool) {
+		content, ok := s.Files[name]
+		return content, ok
+	})
+}
+
+func (s *FileStorageMock) Load(ctx context.Context, name string) ([]byte, error) {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	content, ok := s.Files[name]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+
+	return content, nil
+}
+
+// Names returns the sorted names of the saved files.
+func (s *FileStorageMock) Names() []string {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,223 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/sirupsen/logrus"
+)
+
+const correlationIDHeader = "Correlation-ID"
+
+// StatusError is returned when a service answers with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %v", e.StatusCode)
+}
+
+// HTTPClientConfig tunes the HTTP client used to call a service.
+type HTTPClientConfig struct {
+	// Timeout bounds each attempt of a call, reading its response included,
+	// so a slow attempt still leaves time for the retries.
+	Timeout time.Duration
+
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+
+	// Retries is the number of extra attempts of idempotent requests (GET, PUT, DELETE...)
+	// failed by a network error, a 429 or a 5xx status code.
+	Retries int
+	// RetryBaseDelay and RetryMaxDelay bound the exponential backoff, which uses full jitter.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Timeout:             10 * time.Second,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     50,
+		IdleConnTimeout:     90 * time.Second,
+		Retries:             3,
+		RetryBaseDelay:      100 * time.Millisecond,
+		RetryMaxDelay:       2 * time.Second,
+	}
+}
+
+func newHTTPClient(service string, config HTTPClientConfig) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        config.MaxIdleConnsPerHost,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
+		IdleConnTimeout:     config.IdleConnTimeout,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: retryTransport{
+			config: config,
+			next: loggingTransport{
+				service: service,
+				next:    transport,
+			},
+		},
+	}
+}
+
+// retryTransport retries the idempotent requests on transient errors.
+type retryTransport struct {
+	config HTTPClientConfig
+	next   http.RoundTripper
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) || t.config.Retries <= 0 {
+		return t.attempt(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.attempt(attemptReq)
+		if attempt >= t.config.Retries || !isTransient(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body, so the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.backoff(attempt)):
+		}
+	}
+}
+
+// attempt sends the request once, bounded by the timeout. The timeout covers reading
+// the response body too, so it is only released once the body is closed.
+func (t retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.config.Timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.config.Timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (t retryTransport) backoff(attempt int) time.Duration {
+	delay := t.config.RetryBaseDelay << attempt
+	if delay <= 0 || delay > t.config.RetryMaxDelay {
+		delay = t.config.RetryMaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay)
+}
+
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("unable to retry %s %s: request body can't be rewound", req.Method, req.URL)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	attemptReq := req.Clone(req.Context())
+	attemptReq.Body = body
+	return attemptReq, nil
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// loggingTransport logs each request and its response. The logger of the context
+// already carries the correlation ID.
+type loggingTransport struct {
+	service string
+	next    http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	logger := log.FromContext(req.Context()).WithFields(logrus.Fields{
+		"service":  t.service,
+		"method":   req.Method,
+		"url":      req.URL.String(),
+		"duration": time.Since(start).String(),
+	})
+	if err != nil {
+		logger.WithError(err).Warn("HTTP request failed")
+		return nil, err
+	}
+
+	logger.WithField("status", resp.StatusCode).Debug("HTTP request done")
+	return resp, nil
+}
+
+func correlationIDEditor(ctx context.Context, req *http.Request) error {
+	req.Header.Set(correlationIDHeader, log.CorrelationIDFromContext(ctx))
+	return nil
+}
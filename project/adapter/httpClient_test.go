@@ -0,0 +1,113 @@
+package adapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientsWithConfig_retriesIdempotentCalls(t *testing.T) {
+	var receiptAttempts, rowAttempts atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/receipts-api/receipts":
+			if receiptAttempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			var body receipts.CreateReceipt
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body), "request body must be sent on every attempt")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(receipts.Receipt{
+				Number:   "receipt-1",
+				TicketId: body.TicketId,
+			})
+		case "/spreadsheets-api/sheets/tickets-to-print/rows":
+			rowAttempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(gateway.Close)
+
+	config := adapter.DefaultClientsConfig(gateway.URL)
+	config.Receipts.RetryBaseDelay = time.Millisecond
+	config.Spreadsheets.RetryBaseDelay = time.Millisecond
+	clients := adapter.NewClientsWithConfig(config)
+
+	resp, err := clients.Receipts.IssueReceipt(context.Background(), adapter.IssueReceiptRequest{
+		TicketID: "ticket-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "receipt-1", resp.ReceiptNumber)
+	assert.EqualValues(t, 3, receiptAttempts.Load())
+
+	// Appending a row is not idempotent, so it is never retried.
+	err = clients.Spreadsheets.AppendRow(context.Background(), "tickets-to-print", []string{"ticket-1"})
+	require.Error(t, err)
+	assert.EqualValues(t, 1, rowAttempts.Load())
+}
+
+func TestNewClientsWithConfig_serviceAddrOverridesGateway(t *testing.T) {
+	var paths []string
+	receiptsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(receipts.Receipt{Number: "receipt-1"})
+	}))
+	t.Cleanup(receiptsAPI.Close)
+
+	config := adapter.DefaultClientsConfig("http://gateway.invalid")
+	config.ReceiptsAddr = receiptsAPI.URL
+	clients := adapter.NewClientsWithConfig(config)
+
+	_, err := clients.Receipts.IssueReceipt(context.Background(), adapter.IssueReceiptRequest{
+		TicketID: "ticket-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/receipts"}, paths)
+}
+
+func TestNewClientsWithConfig_timeoutBoundsEachAttempt(t *testing.T) {
+	var attempts atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(receipts.Receipt{Number: "receipt-1"})
+	}))
+	t.Cleanup(gateway.Close)
+
+	config := adapter.DefaultClientsConfig(gateway.URL)
+	config.Receipts.Timeout = 100 * time.Millisecond
+	config.Receipts.RetryBaseDelay = time.Millisecond
+	clients := adapter.NewClientsWithConfig(config)
+
+	// The first attempt times out, and the retry still has the whole timeout to succeed.
+	resp, err := clients.Receipts.IssueReceipt(context.Background(), adapter.IssueReceiptRequest{
+		TicketID: "ticket-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "receipt-1", resp.ReceiptNumber)
+	assert.EqualValues(t, 2, attempts.Load())
+}
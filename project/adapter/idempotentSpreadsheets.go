@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// appendClaimTTL bounds the time an append holds its row key. It is longer than an append,
+// retries included, so the claim only expires when the delivery holding it crashed.
+const appendClaimTTL = time.Minute
+
+// ErrRowBeingAppended is returned when another delivery is appending the same row.
+// The message is retried, and by then the row is either appended or the claim released.
+var ErrRowBeingAppended = errors.New("row being appended by another delivery")
+
+// RowKey identifies the row appended to a sheet because of an event about a ticket.
+type RowKey struct {
+	Sheet     string
+	TicketID  string
+	EventType string
+}
+
+func (k RowKey) String() string {
+	return fmt.Sprintf("sheet-row:%s:%s:%s", k.Sheet, k.TicketID, k.EventType)
+}
+
+// IdempotentSpreadsheets decorates a SpreadsheetsAPI with an idempotent append mode,
+// which records the appended rows in a DedupeStore so retries never append them again.
+type IdempotentSpreadsheets struct {
+	SpreadsheetsAPI
+	appended DedupeStore
+}
+
+func NewIdempotentSpreadsheets(api SpreadsheetsAPI, appended DedupeStore) IdempotentSpreadsheets {
+	return IdempotentSpreadsheets{
+		SpreadsheetsAPI: api,
+		appended:        appended,
+	}
+}
+
+// AppendRowOnce appends the row unless a row with the same key was already appended.
+// The key is claimed before appending, so concurrent deliveries of the same row don't both
+// append it: the one not holding the claim fails with ErrRowBeingAppended. The row is recorded
+// after appending it, so only a crash in between, once the claim expires, can duplicate it.
+func (s IdempotentSpreadsheets) AppendRowOnce(ctx context.Context, key RowKey, row []string) error {
+	done, err := s.appended.Done(ctx, key.String())
+	if err != nil {
+		return fmt.Errorf("unable to check appended row %s: %w", key, err)
+	}
+	if done {
+		return nil
+	}
+
+	claimed, err := s.appended.Claim(ctx, key.String(), appendClaimTTL)
+	if err != nil {
+		return fmt.Errorf("unable to claim row %s: %w", key, err)
+	}
+	if !claimed {
+		return fmt.Errorf("%w: %s", ErrRowBeingAppended, key)
+	}
+
+	// The row may have been appended between the check and the claim.
+	done, err = s.appended.Done(ctx, key.String())
+	if err != nil || done {
+		return errors.Join(err, s.appended.Release(ctx, key.String()))
+	}
+
+	err = s.AppendRow(ctx, key.Sheet, row)
+	if err != nil {
+		// Lets the next delivery append it, instead of waiting for the claim to expire.
+		return errors.Join(err, s.appended.Release(ctx, key.String()))
+	}
+
+	err = s.appended.MarkDone(ctx, key.String())
+	if err != nil {
+		return fmt.Errorf("unable to record appended row %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentSpreadsheets_AppendRowOnce(t *testing.T) {
+	mock := adapter.NewSpreadsheetsAPIMock()
+	spreadsheets := adapter.NewIdempotentSpreadsheets(mock, adapter.NewDedupeStoreMock())
+	key := adapter.RowKey{Sheet: "tickets-to-print", TicketID: "ticket-1", EventType: "TicketBookingConfirmed"}
+
+	require.NoError(t, spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"}))
+	require.NoError(t, spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"}))
+
+	assert.Len(t, mock.RowsFor("tickets-to-print"), 1)
+	assert.Equal(t, 1, mock.Calls())
+
+	// Another event about the ticket appends its own row.
+	otherKey := key
+	otherKey.EventType = "TicketBookingCanceled"
+	require.NoError(t, spreadsheets.AppendRowOnce(context.Background(), otherKey, []string{"ticket-1"}))
+
+	assert.Len(t, mock.RowsFor("tickets-to-print"), 2)
+}
+
+func TestIdempotentSpreadsheets_AppendRowOnce_failedAppendIsRetried(t *testing.T) {
+	mock := adapter.NewSpreadsheetsAPIMock()
+	mock.FailFor("ticket-1", nil)
+	spreadsheets := adapter.NewIdempotentSpreadsheets(mock, adapter.NewDedupeStoreMock())
+	key := adapter.RowKey{Sheet: "tickets-to-print", TicketID: "ticket-1", EventType: "TicketBookingConfirmed"}
+
+	err := spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"})
+	require.Error(t, err)
+
+	mock.Recover("ticket-1")
+	require.NoError(t, spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"}))
+
+	assert.Len(t, mock.RowsFor("tickets-to-print"), 1)
+	assert.Equal(t, 2, mock.Calls())
+}
+
+func TestIdempotentSpreadsheets_AppendRowOnce_concurrentDeliveries(t *testing.T) {
+	mock := adapter.NewSpreadsheetsAPIMock()
+	mock.SetLatency(50 * time.Millisecond)
+	spreadsheets := adapter.NewIdempotentSpreadsheets(mock, adapter.NewDedupeStoreMock())
+	key := adapter.RowKey{Sheet: "tickets-to-print", TicketID: "ticket-1", EventType: "TicketBookingConfirmed"}
+
+	// Both deliveries check the row before any of them appends it.
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			errs <- spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"})
+		}()
+	}
+
+	var failed []error
+	for range 2 {
+		if err := <-errs; err != nil {
+			failed = append(failed, err)
+		}
+	}
+	require.Len(t, failed, 1)
+	assert.ErrorIs(t, failed[0], adapter.ErrRowBeingAppended)
+	assert.Len(t, mock.RowsFor("tickets-to-print"), 1)
+
+	// The retry of the delivery that lost the claim finds the row appended.
+	require.NoError(t, spreadsheets.AppendRowOnce(context.Background(), key, []string{"ticket-1"}))
+	assert.Len(t, mock.RowsFor("tickets-to-print"), 1)
+}
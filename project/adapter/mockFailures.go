@@ -0,0 +1,141 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MockFailures scripts the failures and latency of a mock, so tests can exercise
+// retries, timeouts and duplicates. It is safe to change it while the mock is in use.
+type MockFailures struct {
+	failures sync.Mutex
+
+	latency     time.Duration
+	failNext    int
+	failNextErr error
+	failTickets map[string]error
+	calls       int
+	failedCalls int
+}
+
+// FailNext makes the next n calls fail with err, or with a 500 StatusError when err is nil.
+func (f *MockFailures) FailNext(n int, err error) {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	f.failNext = n
+	f.failNextErr = err
+}
+
+// FailFor makes every call about the ticket fail with err, or with a 500 StatusError
+// when err is nil, until Recover is called.
+func (f *MockFailures) FailFor(ticketID string, err error) {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	if f.failTickets == nil {
+		f.failTickets = map[string]error{}
+	}
+	f.failTickets[ticketID] = err
+}
+
+// Recover stops failing the calls about the ticket.
+func (f *MockFailures) Recover(ticketID string) {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	delete(f.failTickets, ticketID)
+}
+
+// SetLatency delays every call by d, or until the call context is done.
+func (f *MockFailures) SetLatency(d time.Duration) {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	f.latency = d
+}
+
+// Reset removes all the scripted failures and the latency.
+func (f *MockFailures) Reset() {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	f.latency = 0
+	f.failNext = 0
+	f.failNextErr = nil
+	f.failTickets = nil
+}
+
+// Calls returns how many calls the mock got, including the failed ones.
+func (f *MockFailures) Calls() int {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	return f.calls
+}
+
+// FailedCalls returns how many calls have been failed on purpose.
+func (f *MockFailures) FailedCalls() int {
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	return f.failedCalls
+}
+
+// before applies the scripted behaviour to a call about the given tickets.
+// It must be called before the mock takes its own lock, so latency doesn't block other calls.
+func (f *MockFailures) before(ctx context.Context, ticketIDs ...string) error {
+	if err := f.wait(ctx); err != nil {
+		return err
+	}
+
+	f.failures.Lock()
+	defer f.failures.Unlock()
+
+	if f.failNext > 0 {
+		f.failNext--
+		return f.fail(f.failNextErr)
+	}
+
+	return f.failTicket(ticketIDs)
+}
+
+// wait counts the call and applies the latency.
+func (f *MockFailures) wait(ctx context.Context) error {
+	f.failures.Lock()
+	f.calls++
+	latency := f.latency
+	f.failures.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+
+	return nil
+}
+
+func (f *MockFailures) failTicket(ticketIDs []string) error {
+	for ticketID, err := range f.failTickets {
+		if slices.Contains(ticketIDs, ticketID) {
+			return f.fail(err)
+		}
+	}
+
+	return nil
+}
+
+func (f *MockFailures) fail(err error) error {
+	f.failedCalls++
+
+	if err == nil {
+		return StatusError{StatusCode: http.StatusInternalServerError}
+	}
+	return err
+}
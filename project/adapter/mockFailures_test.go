@@ -0,0 +1,74 @@
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockFailures(t *testing.T) {
+	ctx := context.Background()
+	receipts := adapter.NewReceiptsServiceMock()
+
+	receipts.FailNext(2, nil)
+	for range 2 {
+		_, err := receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+		assert.Equal(t, adapter.StatusError{StatusCode: http.StatusInternalServerError}, err)
+	}
+	_, err := receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-1"})
+	require.NoError(t, err)
+
+	errConflict := adapter.StatusError{StatusCode: http.StatusConflict}
+	receipts.FailFor("ticket-2", errConflict)
+	_, err = receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-2"})
+	assert.ErrorIs(t, err, errConflict)
+	_, err = receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-3"})
+	require.NoError(t, err)
+
+	receipts.Recover("ticket-2")
+	_, err = receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{TicketID: "ticket-2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, receipts.FailedCalls())
+	assert.Len(t, receipts.Issued(), 3)
+}
+
+func TestMockFailures_latency(t *testing.T) {
+	spreadsheets := adapter.NewSpreadsheetsAPIMock()
+	spreadsheets.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"ticket-1"})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Empty(t, spreadsheets.RowsFor("tickets-to-print"))
+}
+
+func TestMock_waitFor(t *testing.T) {
+	spreadsheets := adapter.NewSpreadsheetsAPIMock()
+	spreadsheets.FailNext(1, nil)
+
+	go func() {
+		for spreadsheets.AppendRow(context.Background(), "tickets-to-print", []string{"ticket-1", "50.00"}) != nil {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	row, err := spreadsheets.WaitForRow(ctx, "tickets-to-print", "ticket-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ticket-1", "50.00"}, row)
+	assert.Equal(t, [][]string{{"ticket-1", "50.00"}}, spreadsheets.RowsFor("tickets-to-print"))
+	assert.Equal(t, 2, spreadsheets.Calls())
+
+	_, err = spreadsheets.WaitForRow(ctx, "tickets-to-refund", "ticket-1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
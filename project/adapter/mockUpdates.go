@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+)
+
+// mockUpdates lets the wait helpers of a mock block until it records a new call.
+// Its methods must be called holding the mutex of the mock.
+type mockUpdates struct {
+	updated chan struct{}
+}
+
+func (u *mockUpdates) notify() {
+	if u.updated != nil {
+		close(u.updated)
+	}
+	u.updated = make(chan struct{})
+}
+
+func (u *mockUpdates) next() <-chan struct{} {
+	if u.updated == nil {
+		u.updated = make(chan struct{})
+	}
+	return u.updated
+}
+
+// waitFor blocks until find succeeds or the context is done. find is called holding mu.
+func waitFor[T any](ctx context.Context, mu *sync.Mutex, updates *mockUpdates, find func() (T, bool)) (T, error) {
+	for {
+		mu.Lock()
+		found, ok := find()
+		next := updates.next()
+		mu.Unlock()
+
+		if ok {
+			return found, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-next:
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package adapter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSMTPAddr    = "localhost:1025"
+	defaultSMTPFrom    = "tickets@example.com"
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+type Notification struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	// HTMLBody is the HTML content of the email.
+	HTMLBody string `json:"html_body"`
+}
+
+type SMTPConfig struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+
+	// Timeout bounds the whole delivery of an email. It defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+func DefaultSMTPConfig() SMTPConfig {
+	return SMTPConfig{
+		Addr:    defaultSMTPAddr,
+		From:    defaultSMTPFrom,
+		Timeout: defaultSMTPTimeout,
+	}
+}
+
+// SMTPNotifier sends the notifications as emails through an SMTP server.
+type SMTPNotifier struct {
+	config SMTPConfig
+	clock  Clock
+}
+
+func NewSMTPNotifier(config SMTPConfig, clock Clock) SMTPNotifier {
+	return SMTPNotifier{
+		config: config,
+		clock:  clock,
+	}
+}
+
+func (n SMTPNotifier) Notify(ctx context.Context, notification Notification) error {
+	err := n.send(ctx, notification)
+	if err != nil {
+		return fmt.Errorf("unable to send email to %s: %w", notification.To, err)
+	}
+
+	return nil
+}
+
+// send does what smtp.SendMail does, but bounded by the context and the timeout,
+// so a hung SMTP server can't block the handler forever.
+func (n SMTPNotifier) send(ctx context.Context, notification Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(n.config.Timeout, defaultSMTPTimeout))
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.config.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return err
+	}
+	// The deadline doesn't cover the cancellation of the context.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	host, _, _ := strings.Cut(n.config.Addr, ":")
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if n.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			err = client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, host))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = client.Mail(n.config.From)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(notification.To)
+	if err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(n.message(notification))
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (n SMTPNotifier) message(notification Notification) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", notification.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.clock.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notification.HTMLBody)
+
+	return msg.Bytes()
+}
@@ -0,0 +1,52 @@
+package adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type NotifierMock struct {
+	mock          sync.Mutex
+	Notifications []Notification
+
+	updates mockUpdates
+}
+
+func NewNotifierMock() *NotifierMock {
+	return &NotifierMock{
+		mock:          sync.Mutex{},
+		Notifications: []Notification{},
+	}
+}
+
+func (n *NotifierMock) Notify(ctx context.Context, notification Notification) error {
+	n.mock.Lock()
+	defer n.mock.Unlock()
+
+	n.Notifications = append(n.Notifications, notification)
+	n.updates.notify()
+	return nil
+}
+
+// Sent returns a snapshot of the sent notifications.
+func (n *NotifierMock) Sent() []Notification {
+	n.mock.Lock()
+	defer n.mock.Unlock()
+
+	return slices.Clone(n.Notifications)
+}
+
+// WaitForNotification blocks until a notification with the subject is sent to the
+// recipient or the context is done.
+func (n *NotifierMock) WaitForNotification(ctx context.Context, to string, subject string) (Notification, error) {
+	return waitFor(ctx, &n.mock, &n.updates, func() (Notification, bool) {
+		index := slices.IndexFunc(n.Notifications, func(notification Notification) bool {
+			return notification.To == to && notification.Subject == subject
+		})
+		if index == -1 {
+			return Notification{}, false
+		}
+		return n.Notifications[index], true
+	})
+}
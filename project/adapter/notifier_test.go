@@ -0,0 +1,204 @@
+package adapter_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPNotifier(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	notifier := adapter.NewSMTPNotifier(adapter.SMTPConfig{
+		Addr: server.Addr(),
+		From: "tickets@example.com",
+	}, adapter.NewClockMock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	err := notifier.Notify(context.Background(), adapter.Notification{
+		To:       "truman@capote.com",
+		Subject:  "Your ticket is confirmed",
+		HTMLBody: "<p>See you at the show!</p>",
+	})
+	require.NoError(t, err)
+
+	mails := server.Mails()
+	require.Len(t, mails, 1)
+	assert.Equal(t, "tickets@example.com", mails[0].From)
+	assert.Equal(t, []string{"truman@capote.com"}, mails[0].To)
+	assert.Contains(t, mails[0].Data, "Subject: Your ticket is confirmed")
+	assert.Contains(t, mails[0].Data, "Date: Wed, 01 May 2024 12:00:00 +0000")
+	assert.Contains(t, mails[0].Data, "<p>See you at the show!</p>")
+}
+
+func TestSMTPNotifier_hungServer(t *testing.T) {
+	// The server accepts connections, but never answers.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+		}
+	}()
+
+	notification := adapter.Notification{To: "truman@capote.com", Subject: "Your ticket is confirmed"}
+
+	t.Run("timeout", func(t *testing.T) {
+		notifier := adapter.NewSMTPNotifier(adapter.SMTPConfig{
+			Addr:    listener.Addr().String(),
+			From:    "tickets@example.com",
+			Timeout: 50 * time.Millisecond,
+		}, adapter.SystemClock{})
+
+		start := time.Now()
+		err := notifier.Notify(context.Background(), notification)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		notifier := adapter.NewSMTPNotifier(adapter.SMTPConfig{
+			Addr: listener.Addr().String(),
+			From: "tickets@example.com",
+		}, adapter.SystemClock{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := notifier.Notify(ctx, notification)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+type fakeMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer speaks the minimum SMTP needed by net/smtp to deliver a mail.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	mails []fakeMail
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeSMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) Mails() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]fakeMail(nil), s.mails...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 fake-smtp ready")
+
+	var mail fakeMail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 fake-smtp")
+		case "MAIL":
+			mail = fakeMail{From: smtpAddress(command)}
+			reply("250 OK")
+		case "RCPT":
+			mail.To = append(mail.To, smtpAddress(command))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			mail.Data = data.String()
+
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func smtpAddress(command string) string {
+	start := strings.Index(command, "<")
+	end := strings.Index(command, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+
+	return command[start+1 : end]
+}
@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+)
+
+type PaymentsService interface {
+	RefundPayment(ctx context.Context, request RefundPaymentRequest) (RefundPaymentResponse, error)
+}
+
+type RefundPaymentRequest struct {
+	PaymentReference string `json:"payment_reference"`
+	Reason           string `json:"reason"`
+
+	// DeduplicationID makes retries of the same refund to be refunded only once.
+	DeduplicationID string `json:"deduplication_id"`
+}
+
+type RefundPaymentResponse struct {
+	RefundReference string `json:"refund_reference"`
+}
+
+type PaymentsClient struct {
+	clients *clients.Clients
+}
+
+func NewPaymentsClient(clients *clients.Clients) PaymentsClient {
+	return PaymentsClient{
+		clients: clients,
+	}
+}
+
+// RefundPayment refunds the payment. The payments API does not return a refund
+// reference, so the deduplication ID identifies the refund.
+func (c PaymentsClient) RefundPayment(ctx context.Context, request RefundPaymentRequest) (RefundPaymentResponse, error) {
+	refundsResp, err := c.clients.Payments.PutRefundsWithResponse(ctx, payments.PutRefundsJSONRequestBody{
+		PaymentReference: request.PaymentReference,
+		Reason:           request.Reason,
+		DeduplicationId:  &request.DeduplicationID,
+	})
+	if err != nil {
+		return RefundPaymentResponse{}, err
+	}
+
+	switch refundsResp.StatusCode() {
+	case http.StatusOK, http.StatusCreated:
+		return RefundPaymentResponse{
+			RefundReference: request.DeduplicationID,
+		}, nil
+
+	default:
+		return RefundPaymentResponse{}, StatusError{StatusCode: refundsResp.StatusCode()}
+	}
+}
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type PaymentsServiceMock struct {
+	MockFailures
+
+	mock    sync.Mutex
+	Refunds []RefundPaymentRequest
+
+	updates mockUpdates
+}
+
+func NewPaymentsServiceMock() *PaymentsServiceMock {
+	return &PaymentsServiceMock{
+		mock:    sync.Mutex{},
+		Refunds: []RefundPaymentRequest{},
+	}
+}
+
+func (p *PaymentsServiceMock) RefundPayment(ctx context.Context, request RefundPaymentRequest) (RefundPaymentResponse, error) {
+	if err := p.before(ctx, request.PaymentReference); err != nil {
+		return RefundPaymentResponse{}, err
+	}
+
+	p.mock.Lock()
+	defer p.mock.Unlock()
+
+	response := RefundPaymentResponse{
+		RefundReference: request.DeduplicationID,
+	}
+
+	for _, refund := range p.Refunds {
+		if refund.DeduplicationID == request.DeduplicationID {
+			return response, nil
+		}
+	}
+
+	p.Refunds = append(p.Refunds, request)
+	p.updates.notify()
+	return response, nil
+}
+
+// Refunded returns a snapshot of the refunds.
+func (p *PaymentsServiceMock) Refunded() []RefundPaymentRequest {
+	p.mock.Lock()
+	defer p.mock.Unlock()
+
+	return slices.Clone(p.Refunds)
+}
+
+// WaitForRefund blocks until the payment is refunded or the context is done.
+func (p *PaymentsServiceMock) WaitForRefund(ctx context.Context, paymentReference string) (RefundPaymentRequest, error) {
+	return waitFor(ctx, &p.mock, &p.updates, func() (RefundPaymentRequest, bool) {
+		index := slices.IndexFunc(p.Refunds, func(request RefundPaymentRequest) bool {
+			return request.PaymentReference == paymentReference
+		})
+		if index == -1 {
+			return RefundPaymentRequest{}, false
+		}
+		return p.Refunds[index], true
+	})
+}
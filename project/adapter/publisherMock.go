@@ -0,0 +1,51 @@
+package adapter
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+type PublishedMessage struct {
+	Topic   string
+	Message *message.Message
+}
+
+// PublisherMock keeps the published messages instead of sending them.
+type PublisherMock struct {
+	mock     sync.Mutex
+	Messages []PublishedMessage
+}
+
+func NewPublisherMock() *PublisherMock {
+	return &PublisherMock{
+		mock:     sync.Mutex{},
+		Messages: []PublishedMessage{},
+	}
+}
+
+func (p *PublisherMock) Publish(topic string, messages ...*message.Message) error {
+	p.mock.Lock()
+	defer p.mock.Unlock()
+
+	for _, msg := range messages {
+		p.Messages = append(p.Messages, PublishedMessage{
+			Topic:   topic,
+			Message: msg.Copy(),
+		})
+	}
+	return nil
+}
+
+func (p *PublisherMock) Close() error {
+	return nil
+}
+
+// PublishedMessages returns a snapshot of the published messages.
+func (p *PublisherMock) PublishedMessages() []PublishedMessage {
+	p.mock.Lock()
+	defer p.mock.Unlock()
+
+	return slices.Clone(p.Messages)
+}
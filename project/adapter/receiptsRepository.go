@@ -0,0 +1,93 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrReceiptNotFound = errors.New("receipt not found")
+	// ErrInvalidTicketID is returned when adding a receipt of a ticket whose ID is not a UUID.
+	// Adding it again always fails the same way.
+	ErrInvalidTicketID = errors.New("invalid ticket ID")
+)
+
+type ReceiptsRepository interface {
+	// Add stores the receipt of a ticket. Adding again the receipt of a ticket is a no-op.
+	Add(ctx context.Context, receipt IssuedReceipt) error
+	FindByTicketID(ctx context.Context, ticketID string) (IssuedReceipt, error)
+}
+
+type IssuedReceipt struct {
+	TicketID      string    `json:"ticket_id" db:"ticket_id"`
+	ReceiptNumber string    `json:"receipt_number" db:"receipt_number"`
+	IssuedAt      time.Time `json:"issued_at" db:"issued_at"`
+}
+
+type ReceiptsPostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewReceiptsPostgresRepository(db *sqlx.DB) ReceiptsPostgresRepository {
+	return ReceiptsPostgresRepository{
+		db: db,
+	}
+}
+
+func (r ReceiptsPostgresRepository) Add(ctx context.Context, receipt IssuedReceipt) error {
+	err := validateTicketID(receipt.TicketID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.NamedExecContext(
+		ctx,
+		`INSERT INTO receipts (ticket_id, receipt_number, issued_at)
+		VALUES (:ticket_id, :receipt_number, :issued_at)
+		ON CONFLICT (ticket_id) DO NOTHING`,
+		receipt,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to add receipt of ticket %s: %w", receipt.TicketID, err)
+	}
+
+	return nil
+}
+
+func (r ReceiptsPostgresRepository) FindByTicketID(ctx context.Context, ticketID string) (IssuedReceipt, error) {
+	if validateTicketID(ticketID) != nil {
+		return IssuedReceipt{}, ErrReceiptNotFound
+	}
+
+	var receipt IssuedReceipt
+	err := r.db.GetContext(
+		ctx,
+		&receipt,
+		`SELECT ticket_id, receipt_number, issued_at FROM receipts WHERE ticket_id = $1`,
+		ticketID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return IssuedReceipt{}, ErrReceiptNotFound
+	}
+	if err != nil {
+		return IssuedReceipt{}, fmt.Errorf("unable to find receipt of ticket %s: %w", ticketID, err)
+	}
+
+	return receipt, nil
+}
+
+// validateTicketID checks the ticket ID fits the UUID column of the receipts.
+func validateTicketID(ticketID string) error {
+	_, err := uuid.Parse(ticketID)
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidTicketID, ticketID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+)
+
+type ReceiptsRepositoryMock struct {
+	mock     sync.Mutex
+	Receipts map[string]IssuedReceipt
+}
+
+func NewReceiptsRepositoryMock() *ReceiptsRepositoryMock {
+	return &ReceiptsRepositoryMock{
+		mock:     sync.Mutex{},
+		Receipts: map[string]IssuedReceipt{},
+	}
+}
+
+// Add rejects the ticket IDs that are not UUIDs, like the Postgres repository.
+func (r *ReceiptsRepositoryMock) Add(ctx context.Context, receipt IssuedReceipt) error {
+	err := validateTicketID(receipt.TicketID)
+	if err != nil {
+		return err
+	}
+
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	if _, ok := r.Receipts[receipt.TicketID]; !ok {
+		r.Receipts[receipt.TicketID] = receipt
+	}
+
+	return nil
+}
+
+func (r *ReceiptsRepositoryMock) FindByTicketID(ctx context.Context, ticketID string) (IssuedReceipt, error) {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	receipt, ok := r.Receipts[ticketID]
+	if !ok {
+		return IssuedReceipt{}, ErrReceiptNotFound
+	}
+
+	return receipt, nil
+}
+
+// Stored returns a snapshot of the stored receipts.
+func (r *ReceiptsRepositoryMock) Stored() []IssuedReceipt {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	receipts := make([]IssuedReceipt, 0, len(r.Receipts))
+	for _, receipt := range r.Receipts {
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts
+}
@@ -2,7 +2,6 @@ package adapter
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
@@ -12,11 +11,15 @@ import (
 
 type ReceiptsService interface {
 	IssueReceipt(ctx context.Context, request IssueReceiptRequest) (IssueReceiptResponse, error)
+	VoidReceipt(ctx context.Context, request VoidReceiptRequest) error
 }
 
 type IssueReceiptRequest struct {
 	TicketID string `json:"ticket_id"`
 	Price    Money  `json:"price"`
+
+	// IdempotencyKey makes retries of the same request to return the receipt already issued.
+	IdempotencyKey string `json:"idempotency_key"`
 }
 
 type Money struct {
@@ -29,6 +32,14 @@ type IssueReceiptResponse struct {
 	IssuedAt      time.Time `json:"issued_at"`
 }
 
+type VoidReceiptRequest struct {
+	TicketID string `json:"ticket_id"`
+	Reason   string `json:"reason"`
+}
+
+// IdempotencyKeyHeader is the header sent with the receipt idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 type ReceiptsClient struct {
 	clients *clients.Clients
 }
@@ -45,12 +56,20 @@ func (c ReceiptsClient) IssueReceipt(ctx context.Context, request IssueReceiptRe
 			MoneyAmount:   request.Price.Amount,
 			MoneyCurrency: request.Price.Currency,
 		},
-		TicketId: request.TicketID,
+		TicketId:       request.TicketID,
+		IdempotencyKey: idempotencyKey(request.IdempotencyKey),
+	}, func(ctx context.Context, req *http.Request) error {
+		if request.IdempotencyKey != "" {
+			req.Header.Set(IdempotencyKeyHeader, request.IdempotencyKey)
+		}
+		return nil
 	})
 	if err != nil {
 		return IssueReceiptResponse{}, err
 	}
 
+	// The receipts API answers 201 when it issues the receipt, and 200 with the receipt
+	// already issued when the idempotency key was used before.
 	switch receiptsResp.StatusCode() {
 	case http.StatusOK:
 		return IssueReceiptResponse{
@@ -65,7 +84,30 @@ func (c ReceiptsClient) IssueReceipt(ctx context.Context, request IssueReceiptRe
 		}, nil
 
 	default:
-		return IssueReceiptResponse{}, fmt.Errorf("unexpected status code: %v", receiptsResp.StatusCode())
+		return IssueReceiptResponse{}, StatusError{StatusCode: receiptsResp.StatusCode()}
+	}
+}
+
+func (c ReceiptsClient) VoidReceipt(ctx context.Context, request VoidReceiptRequest) error {
+	voidResp, err := c.clients.Receipts.PutVoidReceiptWithResponse(ctx, receipts.PutVoidReceiptJSONRequestBody{
+		Reason:   request.Reason,
+		TicketId: request.TicketID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if voidResp.StatusCode() != http.StatusOK {
+		return StatusError{StatusCode: voidResp.StatusCode()}
+	}
+
+	return nil
+}
+
+func idempotencyKey(key string) *string {
+	if key == "" {
+		return nil
 	}
 
+	return &key
 }
@@ -2,31 +2,113 @@ package adapter
 
 import (
 	"context"
+	"slices"
 	"sync"
-	"time"
-
-	"github.com/lithammer/shortuuid"
 )
 
 type ReceiptsServiceMock struct {
+	MockFailures
+
+	// Clock and IDs set the time and the number of the issued receipts.
+	// They must be set before the mock is used.
+	Clock Clock
+	IDs   IDGenerator
+
 	mock           sync.Mutex
-	IssuedReceipts []IssueReceiptRequest
+	issuedReceipts []IssueReceiptRequest
+	voidedReceipts []VoidReceiptRequest
+
+	issuedByKey map[string]IssueReceiptResponse
+	updates     mockUpdates
 }
 
 func NewReceiptsServiceMock() *ReceiptsServiceMock {
 	return &ReceiptsServiceMock{
+		Clock:          SystemClock{},
+		IDs:            ShortUUIDGenerator{},
 		mock:           sync.Mutex{},
-		IssuedReceipts: []IssueReceiptRequest{},
+		issuedReceipts: []IssueReceiptRequest{},
+		voidedReceipts: []VoidReceiptRequest{},
+		issuedByKey:    map[string]IssueReceiptResponse{},
 	}
 }
 
 func (r *ReceiptsServiceMock) IssueReceipt(ctx context.Context, request IssueReceiptRequest) (IssueReceiptResponse, error) {
+	if err := r.before(ctx, request.TicketID); err != nil {
+		return IssueReceiptResponse{}, err
+	}
+
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	if issued, ok := r.issuedByKey[request.IdempotencyKey]; ok && request.IdempotencyKey != "" {
+		return issued, nil
+	}
+
+	r.issuedReceipts = append(r.issuedReceipts, request)
+	r.updates.notify()
+	issued := IssueReceiptResponse{
+		ReceiptNumber: r.IDs.NewID(ctx),
+		IssuedAt:      r.Clock.Now(),
+	}
+	if request.IdempotencyKey != "" {
+		r.issuedByKey[request.IdempotencyKey] = issued
+	}
+
+	return issued, nil
+}
+
+func (r *ReceiptsServiceMock) VoidReceipt(ctx context.Context, request VoidReceiptRequest) error {
+	if err := r.before(ctx, request.TicketID); err != nil {
+		return err
+	}
+
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	r.voidedReceipts = append(r.voidedReceipts, request)
+	r.updates.notify()
+	return nil
+}
+
+// Issued returns a snapshot of the issued receipts.
+func (r *ReceiptsServiceMock) Issued() []IssueReceiptRequest {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	return slices.Clone(r.issuedReceipts)
+}
+
+// Voided returns a snapshot of the voided receipts.
+func (r *ReceiptsServiceMock) Voided() []VoidReceiptRequest {
 	r.mock.Lock()
 	defer r.mock.Unlock()
 
-	r.IssuedReceipts = append(r.IssuedReceipts, request)
-	return IssueReceiptResponse{
-		ReceiptNumber: shortuuid.New(),
-		IssuedAt:      time.Now(),
-	}, nil
+	return slices.Clone(r.voidedReceipts)
+}
+
+// WaitForReceipt blocks until a receipt is issued for the ticket or the context is done.
+func (r *ReceiptsServiceMock) WaitForReceipt(ctx context.Context, ticketID string) (IssueReceiptRequest, error) {
+	return waitFor(ctx, &r.mock, &r.updates, func() (IssueReceiptRequest, bool) {
+		index := slices.IndexFunc(r.issuedReceipts, func(request IssueReceiptRequest) bool {
+			return request.TicketID == ticketID
+		})
+		if index == -1 {
+			return IssueReceiptRequest{}, false
+		}
+		return r.issuedReceipts[index], true
+	})
+}
+
+// WaitForVoid blocks until the receipt of the ticket is voided or the context is done.
+func (r *ReceiptsServiceMock) WaitForVoid(ctx context.Context, ticketID string) (VoidReceiptRequest, error) {
+	return waitFor(ctx, &r.mock, &r.updates, func() (VoidReceiptRequest, bool) {
+		index := slices.IndexFunc(r.voidedReceipts, func(request VoidReceiptRequest) bool {
+			return request.TicketID == ticketID
+		})
+		if index == -1 {
+			return VoidReceiptRequest{}, false
+		}
+		return r.voidedReceipts[index], true
+	})
 }
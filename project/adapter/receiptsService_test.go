@@ -0,0 +1,95 @@
+package adapter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsClient_IssueReceipt_duplicated(t *testing.T) {
+	issuedAt := time.Date(2024, 12, 10, 10, 0, 0, 0, time.UTC)
+
+	// The stub follows the receipts API spec: 201 with the new receipt, or 200 with the
+	// receipt already issued for the idempotency key of the request body.
+	issued := map[string]receipts.Receipt{}
+	var idempotencyKeys []string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request receipts.CreateReceipt
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.IdempotencyKey == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		idempotencyKeys = append(idempotencyKeys, *request.IdempotencyKey)
+
+		w.Header().Set("Content-Type", "application/json")
+		receipt, ok := issued[*request.IdempotencyKey]
+		if ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			receipt = receipts.Receipt{
+				Number:   "receipt-" + request.TicketId,
+				IssuedAt: issuedAt,
+				TicketId: request.TicketId,
+			}
+			issued[*request.IdempotencyKey] = receipt
+			w.WriteHeader(http.StatusCreated)
+		}
+		_ = json.NewEncoder(w).Encode(receipt)
+	}))
+	t.Cleanup(gateway.Close)
+
+	c, err := clients.NewClients(gateway.URL, nil)
+	require.NoError(t, err)
+	client := adapter.NewReceiptsClient(c)
+
+	request := adapter.IssueReceiptRequest{
+		TicketID: "ticket-1",
+		Price: adapter.Money{
+			Amount:   "50.00",
+			Currency: "USD",
+		},
+		IdempotencyKey: "issue-receipt-ticket-1",
+	}
+
+	first, err := client.IssueReceipt(context.Background(), request)
+	require.NoError(t, err)
+	second, err := client.IssueReceipt(context.Background(), request)
+	require.NoError(t, err)
+
+	assert.Equal(t, "receipt-ticket-1", first.ReceiptNumber)
+	assert.Equal(t, first.ReceiptNumber, second.ReceiptNumber)
+	assert.True(t, issuedAt.Equal(second.IssuedAt))
+	assert.Equal(t, []string{"issue-receipt-ticket-1", "issue-receipt-ticket-1"}, idempotencyKeys)
+}
+
+func TestReceiptsClient_IssueReceipt_conflict(t *testing.T) {
+	// A 409 is not part of the receipts API spec, so it is an error like any other status.
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(receipts.Receipt{Number: "receipt-1", TicketId: "ticket-1"})
+	}))
+	t.Cleanup(gateway.Close)
+
+	c, err := clients.NewClients(gateway.URL, nil)
+	require.NoError(t, err)
+
+	_, err = adapter.NewReceiptsClient(c).IssueReceipt(context.Background(), adapter.IssueReceiptRequest{
+		TicketID:       "ticket-1",
+		IdempotencyKey: "issue-receipt-ticket-1",
+	})
+
+	var statusErr adapter.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusConflict, statusErr.StatusCode)
+}
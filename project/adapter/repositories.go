@@ -0,0 +1,32 @@
+package adapter
+
+import (
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type Repositories struct {
+	Receipts ReceiptsRepository
+}
+
+func NewRepositories(db *sqlx.DB) Repositories {
+	return Repositories{
+		Receipts: NewReceiptsPostgresRepository(db),
+	}
+}
+
+type RepositoryMocks struct {
+	Receipts *ReceiptsRepositoryMock
+}
+
+func NewRepositoriesMock() RepositoryMocks {
+	return RepositoryMocks{
+		Receipts: NewReceiptsRepositoryMock(),
+	}
+}
+
+func (m RepositoryMocks) Repositories() Repositories {
+	return Repositories{
+		Receipts: m.Receipts,
+	}
+}
@@ -0,0 +1,182 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	scheduledMessagesKey = "scheduled-messages"
+	// claimedMessagesKey holds the messages being released, scored by the time they were claimed.
+	claimedMessagesKey           = "scheduled-messages:claimed"
+	defaultSchedulerPollInterval = 500 * time.Millisecond
+	// defaultClaimTimeout is the time a scheduler has to release a claimed message. After it,
+	// the message is due again, so the messages claimed by a crashed scheduler are not lost.
+	defaultClaimTimeout = 30 * time.Second
+	schedulerBatchSize  = 100
+)
+
+// claimDueScript moves the due messages to the claimed ones, atomically, so each is released
+// by a single scheduler. It makes due again the messages claimed for longer than the claim timeout.
+var claimDueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[3])
+for _, member in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], member)
+	redis.call('ZADD', KEYS[1], ARGV[1], member)
+end
+
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(due) do
+	redis.call('ZREM', KEYS[1], member)
+	redis.call('ZADD', KEYS[2], ARGV[1], member)
+end
+
+return due
+`)
+
+// MessageScheduler stores messages that must be delivered later in a Redis sorted set,
+// scored by its delivery time, and releases them to their topic once they are due.
+//
+// A due message is claimed before being published, and only forgotten once published.
+// A scheduler crashing in between makes the message to be published again after the
+// claim timeout, so messages can be delivered twice, but are never lost.
+type MessageScheduler struct {
+	rdb          *redis.Client
+	scheduledKey string
+	claimedKey   string
+	publisher    message.Publisher
+	logger       watermill.LoggerAdapter
+	clock        Clock
+	pollInterval time.Duration
+	claimTimeout time.Duration
+}
+
+type scheduledMessage struct {
+	Topic    string            `json:"topic"`
+	UUID     string            `json:"uuid"`
+	Metadata map[string]string `json:"metadata"`
+	Payload  []byte            `json:"payload"`
+}
+
+// NewMessageScheduler creates a scheduler that releases the due messages using the given publisher,
+// which must not be decorated with the delayed publisher decorator. The clock tells when a message is due,
+// so it must be the one of the delayed publisher decorator. The keyPrefix namespaces the keys
+// of the scheduler, so it only releases the messages scheduled in its namespace.
+func NewMessageScheduler(
+	rdb *redis.Client,
+	publisher message.Publisher,
+	logger watermill.LoggerAdapter,
+	clock Clock,
+	keyPrefix string,
+) *MessageScheduler {
+	return &MessageScheduler{
+		rdb:          rdb,
+		scheduledKey: keyPrefix + scheduledMessagesKey,
+		claimedKey:   keyPrefix + claimedMessagesKey,
+		publisher:    publisher,
+		logger:       logger,
+		clock:        clock,
+		pollInterval: defaultSchedulerPollInterval,
+		claimTimeout: defaultClaimTimeout,
+	}
+}
+
+// Schedule stores the message until deliverAt.
+func (s *MessageScheduler) Schedule(topic string, msg *message.Message, deliverAt time.Time) error {
+	member, err := json.Marshal(scheduledMessage{
+		Topic:    topic,
+		UUID:     msg.UUID,
+		Metadata: msg.Metadata,
+		Payload:  msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal scheduled message: %w", err)
+	}
+
+	return s.rdb.ZAdd(msg.Context(), s.scheduledKey, redis.Z{
+		Score:  float64(deliverAt.UnixMilli()),
+		Member: member,
+	}).Err()
+}
+
+// Run releases the due messages until the context is done.
+func (s *MessageScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := s.releaseDue(ctx)
+			if err != nil {
+				s.logger.Error("Unable to release scheduled messages", err, nil)
+			}
+		}
+	}
+}
+
+func (s *MessageScheduler) releaseDue(ctx context.Context) error {
+	now := s.clock.Now()
+	members, err := claimDueScript.Run(
+		ctx,
+		s.rdb,
+		[]string{s.scheduledKey, s.claimedKey},
+		now.UnixMilli(),
+		schedulerBatchSize,
+		now.Add(-s.claimTimeout).UnixMilli(),
+	).StringSlice()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, member := range members {
+		err := s.release(ctx, member)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to release scheduled message: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *MessageScheduler) release(ctx context.Context, member string) error {
+	var scheduled scheduledMessage
+	err := json.Unmarshal([]byte(member), &scheduled)
+	if err != nil {
+		// A corrupted member can never be released, so it is dropped.
+		s.logger.Error("Dropping invalid scheduled message", err, watermill.LogFields{"member": member})
+		return s.rdb.ZRem(ctx, s.claimedKey, member).Err()
+	}
+
+	msg := message.NewMessage(scheduled.UUID, scheduled.Payload)
+	msg.Metadata = scheduled.Metadata
+	msg.SetContext(ctx)
+
+	err = s.publisher.Publish(scheduled.Topic, msg)
+	if err != nil {
+		// Make it due again, so it is released in the next poll. If this fails too,
+		// the claim timeout makes it due later.
+		_, retryErr := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZRem(ctx, s.claimedKey, member)
+			pipe.ZAdd(ctx, s.scheduledKey, redis.Z{
+				Score:  float64(s.clock.Now().UnixMilli()),
+				Member: member,
+			})
+			return nil
+		})
+		return errors.Join(err, retryErr)
+	}
+
+	// If this fails, the message is published again after the claim timeout.
+	return s.rdb.ZRem(ctx, s.claimedKey, member).Err()
+}
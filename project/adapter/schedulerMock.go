@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+type ScheduledMessage struct {
+	Topic     string
+	Message   *message.Message
+	DeliverAt time.Time
+}
+
+// MessageSchedulerMock keeps the scheduled messages without ever releasing them.
+type MessageSchedulerMock struct {
+	mock      sync.Mutex
+	Scheduled []ScheduledMessage
+}
+
+func NewMessageSchedulerMock() *MessageSchedulerMock {
+	return &MessageSchedulerMock{
+		mock:      sync.Mutex{},
+		Scheduled: []ScheduledMessage{},
+	}
+}
+
+func (s *MessageSchedulerMock) Schedule(topic string, msg *message.Message, deliverAt time.Time) error {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	s.Scheduled = append(s.Scheduled, ScheduledMessage{
+		Topic:     topic,
+		Message:   msg.Copy(),
+		DeliverAt: deliverAt,
+	})
+	return nil
+}
+
+func (s *MessageSchedulerMock) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// ScheduledMessages returns a snapshot of the scheduled messages.
+func (s *MessageSchedulerMock) ScheduledMessages() []ScheduledMessage {
+	s.mock.Lock()
+	defer s.mock.Unlock()
+
+	return slices.Clone(s.Scheduled)
+}
@@ -2,13 +2,14 @@ package adapter
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients"
 	"github.com/ThreeDotsLabs/go-event-driven/common/clients/spreadsheets"
 )
 
+// SpreadsheetsAPI appends the rows one by one: the spreadsheets API has no endpoint
+// to append several rows in a single request.
 type SpreadsheetsAPI interface {
 	AppendRow(ctx context.Context, sheetName string, row []string) error
 }
@@ -33,7 +34,7 @@ func (c SpreadsheetsClient) AppendRow(ctx context.Context, spreadsheetName strin
 		return err
 	}
 	if sheetsResp.StatusCode() != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %v", sheetsResp.StatusCode())
+		return StatusError{StatusCode: sheetsResp.StatusCode()}
 	}
 
 	return nil
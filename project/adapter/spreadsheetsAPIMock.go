@@ -2,30 +2,67 @@ package adapter
 
 import (
 	"context"
+	"slices"
 	"sync"
 )
 
 type SpreadsheetsAPIMock struct {
+	MockFailures
+
 	mock         sync.Mutex
-	AppendedRows map[string][][]string
+	appendedRows map[string][][]string
+
+	updates mockUpdates
 }
 
 func NewSpreadsheetsAPIMock() *SpreadsheetsAPIMock {
 	return &SpreadsheetsAPIMock{
 		mock:         sync.Mutex{},
-		AppendedRows: map[string][][]string{},
+		appendedRows: map[string][][]string{},
 	}
 }
 
 func (r *SpreadsheetsAPIMock) AppendRow(ctx context.Context, sheetName string, row []string) error {
+	// Rows have no ticket ID field, so any cell matching a failing ticket fails the call.
+	if err := r.before(ctx, row...); err != nil {
+		return err
+	}
+
 	r.mock.Lock()
 	defer r.mock.Unlock()
 
-	if len(r.AppendedRows[sheetName]) == 0 {
-		r.AppendedRows[sheetName] = [][]string{row}
+	if len(r.appendedRows[sheetName]) == 0 {
+		r.appendedRows[sheetName] = [][]string{row}
 	} else {
-		r.AppendedRows[sheetName] = append(r.AppendedRows[sheetName], row)
+		r.appendedRows[sheetName] = append(r.appendedRows[sheetName], row)
 	}
+	r.updates.notify()
 
 	return nil
 }
+
+// RowsFor returns a snapshot of the rows appended to the sheet.
+func (r *SpreadsheetsAPIMock) RowsFor(sheetName string) [][]string {
+	r.mock.Lock()
+	defer r.mock.Unlock()
+
+	rows := make([][]string, 0, len(r.appendedRows[sheetName]))
+	for _, row := range r.appendedRows[sheetName] {
+		rows = append(rows, slices.Clone(row))
+	}
+
+	return rows
+}
+
+// WaitForRow blocks until a row about the ticket is appended to the sheet or the context is done.
+func (r *SpreadsheetsAPIMock) WaitForRow(ctx context.Context, sheetName string, ticketID string) ([]string, error) {
+	return waitFor(ctx, &r.mock, &r.updates, func() ([]string, bool) {
+		index := slices.IndexFunc(r.appendedRows[sheetName], func(row []string) bool {
+			return slices.Contains(row, ticketID)
+		})
+		if index == -1 {
+			return nil, false
+		}
+		return slices.Clone(r.appendedRows[sheetName][index]), true
+	})
+}
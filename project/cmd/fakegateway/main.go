@@ -0,0 +1,23 @@
+package main
+
+import (
+	"cmp"
+	"net/http"
+	"os"
+	"tickets/fakegateway"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The fake gateway stands in for the gateway docker image. Point the service at it
+// with GATEWAY_ADDR, and script its faults with PUT /fake/faults/:service.
+func main() {
+	addr := cmp.Or(os.Getenv("FAKE_GATEWAY_ADDR"), ":8888")
+
+	logrus.WithField("addr", addr).Info("Fake gateway starting...")
+
+	err := http.ListenAndServe(addr, fakegateway.New().Handler())
+	if err != nil {
+		panic(err)
+	}
+}
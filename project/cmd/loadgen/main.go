@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"tickets/fakegateway"
+	"tickets/loadgen"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The load generator posts synthetic tickets to the tickets-status webhook of a running
+// service, and serves a fake gateway to see when their effects are done. Run the service
+// with GATEWAY_ADDR pointing at the -gateway address.
+func main() {
+	var config loadgen.Config
+	flag.StringVar(&config.BaseURL, "url", "http://localhost:8080", "address of the tickets API")
+	flag.Float64Var(&config.Rate, "rate", 10, "requests per second")
+	flag.IntVar(&config.BatchSize, "batch", 10, "tickets per request")
+	flag.DurationVar(&config.Duration, "duration", 30*time.Second, "how long the requests are sent")
+	flag.Float64Var(&config.CanceledRatio, "canceled", 0.1, "share of canceled tickets, from 0 to 1")
+	flag.DurationVar(&config.Timeout, "timeout", 30*time.Second, "how long to wait for the effects after the last request")
+	flag.DurationVar(&config.PollInterval, "poll", 0, "how often the effects are checked, 100ms by default")
+	gatewayAddr := flag.String("gateway", ":8888", "address of the fake gateway")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	gateway := fakegateway.New()
+	server := &http.Server{Addr: *gatewayAddr, Handler: gateway.Handler()}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.WithError(err).Fatal("Fake gateway stopped")
+		}
+	}()
+	defer server.Close()
+
+	logrus.WithFields(logrus.Fields{
+		"url":     config.BaseURL,
+		"gateway": *gatewayAddr,
+		"rate":    config.Rate,
+		"batch":   config.BatchSize,
+	}).Info("Load generator starting...")
+
+	report, err := loadgen.Run(ctx, config, loadgen.GatewayEffects{Gateway: gateway})
+	if err != nil {
+		logrus.WithError(err).Fatal("Load generator failed")
+	}
+
+	fmt.Println(report)
+}
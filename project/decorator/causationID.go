@@ -0,0 +1,32 @@
+package decorator
+
+import (
+	"tickets/middleware/asyncMiddleware"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+type CausationPublisherDecorator struct {
+	message.Publisher
+}
+
+func DecorateWithCausationPublisherDecorator(pub message.Publisher) message.Publisher {
+	return CausationPublisherDecorator{pub}
+}
+
+func (c CausationPublisherDecorator) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		c.setCausationIDFromContext(msg)
+	}
+
+	return c.Publisher.Publish(topic, messages...)
+}
+
+func (c CausationPublisherDecorator) setCausationIDFromContext(msg *message.Message) {
+	causationID := asyncMiddleware.CausationIDFromContext(msg.Context())
+	if causationID == "" {
+		return
+	}
+
+	msg.Metadata.Set(asyncMiddleware.CausationIDMetadataKey, causationID)
+}
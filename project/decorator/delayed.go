@@ -0,0 +1,79 @@
+package decorator
+
+import (
+	"fmt"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+type MessageScheduler interface {
+	Schedule(topic string, msg *message.Message, deliverAt time.Time) error
+}
+
+// DelayedPublisherDecorator hands the messages with a delivery time in the future to the scheduler,
+// instead of publishing them. The delivery time is read from the deliver_at or delay metadata.
+type DelayedPublisherDecorator struct {
+	message.Publisher
+	scheduler MessageScheduler
+	clock     adapter.Clock
+}
+
+func DecorateWithDelayedPublisherDecorator(pub message.Publisher, scheduler MessageScheduler, clock adapter.Clock) message.Publisher {
+	return DelayedPublisherDecorator{
+		Publisher: pub,
+		scheduler: scheduler,
+		clock:     clock,
+	}
+}
+
+func (d DelayedPublisherDecorator) Publish(topic string, messages ...*message.Message) error {
+	now := d.clock.Now()
+	immediate := make([]*message.Message, 0, len(messages))
+
+	for _, msg := range messages {
+		deliverAt, err := messageDeliverAt(msg, now)
+		if err != nil {
+			return err
+		}
+
+		if !deliverAt.After(now) {
+			immediate = append(immediate, msg)
+			continue
+		}
+
+		msg.Metadata.Set(adapter.DeliverAtMetadataKey, deliverAt.UTC().Format(time.RFC3339Nano))
+		delete(msg.Metadata, adapter.DelayMetadataKey)
+		err = d.scheduler.Schedule(topic, msg, deliverAt)
+		if err != nil {
+			return fmt.Errorf("unable to schedule message %s: %w", msg.UUID, err)
+		}
+	}
+
+	if len(immediate) == 0 {
+		return nil
+	}
+
+	return d.Publisher.Publish(topic, immediate...)
+}
+
+func messageDeliverAt(msg *message.Message, now time.Time) (time.Time, error) {
+	if deliverAt := msg.Metadata.Get(adapter.DeliverAtMetadataKey); deliverAt != "" {
+		t, err := time.Parse(time.RFC3339Nano, deliverAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s metadata in message %s: %w", adapter.DeliverAtMetadataKey, msg.UUID, err)
+		}
+		return t, nil
+	}
+
+	if delay := msg.Metadata.Get(adapter.DelayMetadataKey); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s metadata in message %s: %w", adapter.DelayMetadataKey, msg.UUID, err)
+		}
+		return now.Add(d), nil
+	}
+
+	return now, nil
+}
@@ -0,0 +1,86 @@
+package decorator_test
+
+import (
+	"testing"
+	"tickets/adapter"
+	"tickets/decorator"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelayedPublisherDecorator(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		metadata      map[string]string
+		wantDeliverAt time.Time
+		wantScheduled bool
+	}{
+		{
+			name:     "without delay",
+			metadata: map[string]string{},
+		},
+		{
+			name:          "with delay",
+			metadata:      map[string]string{adapter.DelayMetadataKey: "10m"},
+			wantDeliverAt: now.Add(10 * time.Minute),
+			wantScheduled: true,
+		},
+		{
+			name:          "with deliver_at in the future",
+			metadata:      map[string]string{adapter.DeliverAtMetadataKey: now.Add(time.Hour).Format(time.RFC3339Nano)},
+			wantDeliverAt: now.Add(time.Hour),
+			wantScheduled: true,
+		},
+		{
+			name:     "with deliver_at in the past",
+			metadata: map[string]string{adapter.DeliverAtMetadataKey: now.Add(-time.Hour).Format(time.RFC3339Nano)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			publisher := adapter.NewPublisherMock()
+			scheduler := adapter.NewMessageSchedulerMock()
+			pub := decorator.DecorateWithDelayedPublisherDecorator(publisher, scheduler, adapter.NewClockMock(now))
+
+			msg := message.NewMessage("uuid-1", []byte("{}"))
+			msg.Metadata = tc.metadata
+
+			err := pub.Publish("topic", msg)
+			require.NoError(t, err)
+
+			if !tc.wantScheduled {
+				assert.Len(t, publisher.PublishedMessages(), 1)
+				assert.Empty(t, scheduler.ScheduledMessages())
+				return
+			}
+
+			assert.Empty(t, publisher.PublishedMessages())
+			scheduled := scheduler.ScheduledMessages()
+			require.Len(t, scheduled, 1)
+			assert.Equal(t, "topic", scheduled[0].Topic)
+			assert.Equal(t, tc.wantDeliverAt, scheduled[0].DeliverAt)
+			assert.Equal(t, tc.wantDeliverAt.Format(time.RFC3339Nano), scheduled[0].Message.Metadata.Get(adapter.DeliverAtMetadataKey))
+			assert.Empty(t, scheduled[0].Message.Metadata.Get(adapter.DelayMetadataKey))
+		})
+	}
+}
+
+func TestDelayedPublisherDecorator_invalidMetadata(t *testing.T) {
+	publisher := adapter.NewPublisherMock()
+	scheduler := adapter.NewMessageSchedulerMock()
+	pub := decorator.DecorateWithDelayedPublisherDecorator(publisher, scheduler, adapter.SystemClock{})
+
+	msg := message.NewMessage("uuid-1", []byte("{}"))
+	msg.Metadata.Set(adapter.DelayMetadataKey, "soon")
+
+	err := pub.Publish("topic", msg)
+	assert.Error(t, err)
+	assert.Empty(t, publisher.PublishedMessages())
+	assert.Empty(t, scheduler.ScheduledMessages())
+}
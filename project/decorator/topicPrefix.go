@@ -0,0 +1,27 @@
+package decorator
+
+import (
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// TopicPrefixPublisherDecorator publishes the messages to the prefixed topic,
+// so several services can share the same Pub/Sub without seeing each other messages.
+type TopicPrefixPublisherDecorator struct {
+	message.Publisher
+	prefix string
+}
+
+func DecorateWithTopicPrefixPublisherDecorator(pub message.Publisher, prefix string) message.Publisher {
+	if prefix == "" {
+		return pub
+	}
+
+	return TopicPrefixPublisherDecorator{
+		Publisher: pub,
+		prefix:    prefix,
+	}
+}
+
+func (d TopicPrefixPublisherDecorator) Publish(topic string, messages ...*message.Message) error {
+	return d.Publisher.Publish(d.prefix+topic, messages...)
+}
@@ -0,0 +1,96 @@
+package booking
+
+import "time"
+
+type Status string
+
+const (
+	StatusInProgress  Status = "in_progress"
+	StatusCompleted   Status = "completed"
+	StatusTimedOut    Status = "timed_out"
+	StatusCompensated Status = "compensated"
+)
+
+// Process tracks the steps of a ticket booking, from its confirmation until
+// all the side effects have been done, or until it is canceled.
+type Process struct {
+	TicketID         string    `json:"ticket_id"`
+	Status           Status    `json:"status"`
+	ReceiptNumber    string    `json:"receipt_number,omitempty"`
+	ReceiptIssued    bool      `json:"receipt_issued"`
+	ReceiptVoided    bool      `json:"receipt_voided"`
+	TicketPrinted    bool      `json:"ticket_printed"`
+	NotificationSent bool      `json:"notification_sent"`
+	StartedAt        time.Time `json:"started_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func NewProcess(ticketID string, now time.Time) *Process {
+	return &Process{
+		TicketID:  ticketID,
+		Status:    StatusInProgress,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Finished reports if the process does not expect more steps.
+func (p *Process) Finished() bool {
+	return p.Status == StatusCompleted || p.Status == StatusCompensated
+}
+
+func (p *Process) MarkReceiptIssued(receiptNumber string, now time.Time) {
+	p.ReceiptIssued = true
+	p.ReceiptNumber = receiptNumber
+	p.touch(now)
+}
+
+func (p *Process) MarkReceiptVoided(now time.Time) {
+	p.ReceiptVoided = true
+	p.touch(now)
+}
+
+func (p *Process) MarkTicketPrinted(now time.Time) {
+	p.TicketPrinted = true
+	p.touch(now)
+}
+
+func (p *Process) MarkNotificationSent(now time.Time) {
+	p.NotificationSent = true
+	p.touch(now)
+}
+
+// TimeOut marks the process as stuck, if it is still in progress.
+// It returns true if the process was timed out by this call.
+func (p *Process) TimeOut(now time.Time) bool {
+	if p.Status != StatusInProgress {
+		return false
+	}
+
+	p.Status = StatusTimedOut
+	p.UpdatedAt = now
+	return true
+}
+
+// Cancel marks the process as compensated, as the booking will not happen.
+func (p *Process) Cancel(now time.Time) {
+	p.Status = StatusCompensated
+	p.UpdatedAt = now
+}
+
+// NeedsReceiptVoid reports if a receipt was issued for a canceled booking and it is still valid.
+func (p *Process) NeedsReceiptVoid() bool {
+	return p.Status == StatusCompensated && p.ReceiptIssued && !p.ReceiptVoided
+}
+
+func (p *Process) touch(now time.Time) {
+	p.UpdatedAt = now
+	if p.Status == StatusCompensated {
+		return
+	}
+
+	// A timed out process can still complete if the missing steps arrive late.
+	if p.ReceiptIssued && p.TicketPrinted && p.NotificationSent {
+		p.Status = StatusCompleted
+	}
+}
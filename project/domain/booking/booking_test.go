@@ -0,0 +1,119 @@
+package booking_test
+
+import (
+	"testing"
+	"tickets/domain/booking"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestProcess_completesOnceAllStepsAreDone(t *testing.T) {
+	process := booking.NewProcess("ticket-1", now)
+	assert.Equal(t, booking.StatusInProgress, process.Status)
+
+	process.MarkReceiptIssued("receipt-1", now.Add(time.Second))
+	process.MarkTicketPrinted(now.Add(2 * time.Second))
+	assert.Equal(t, booking.StatusInProgress, process.Status)
+	assert.False(t, process.Finished())
+
+	process.MarkNotificationSent(now.Add(3 * time.Second))
+	assert.Equal(t, booking.StatusCompleted, process.Status)
+	assert.True(t, process.Finished())
+	assert.Equal(t, "receipt-1", process.ReceiptNumber)
+	assert.Equal(t, now, process.StartedAt)
+	assert.Equal(t, now.Add(3*time.Second), process.UpdatedAt)
+}
+
+func TestProcess_timeOut(t *testing.T) {
+	process := booking.NewProcess("ticket-1", now)
+	process.MarkReceiptIssued("receipt-1", now)
+
+	assert.True(t, process.TimeOut(now.Add(time.Minute)))
+	assert.Equal(t, booking.StatusTimedOut, process.Status)
+	assert.False(t, process.Finished())
+	assert.False(t, process.TimeOut(now.Add(2*time.Minute)), "an already timed out process")
+
+	// The missing steps can still arrive late.
+	process.MarkTicketPrinted(now.Add(3 * time.Minute))
+	process.MarkNotificationSent(now.Add(3 * time.Minute))
+	assert.Equal(t, booking.StatusCompleted, process.Status)
+}
+
+func TestProcess_completedProcessDoesNotTimeOut(t *testing.T) {
+	process := booking.NewProcess("ticket-1", now)
+	process.MarkReceiptIssued("receipt-1", now)
+	process.MarkTicketPrinted(now)
+	process.MarkNotificationSent(now)
+
+	assert.False(t, process.TimeOut(now.Add(time.Minute)))
+	assert.Equal(t, booking.StatusCompleted, process.Status)
+}
+
+func TestProcess_cancel(t *testing.T) {
+	testCases := []struct {
+		name          string
+		steps         func(process *booking.Process)
+		wantVoid      bool
+		wantCompleted bool
+	}{
+		{
+			name:     "before the receipt is issued",
+			steps:    func(process *booking.Process) {},
+			wantVoid: false,
+		},
+		{
+			name: "after the receipt is issued",
+			steps: func(process *booking.Process) {
+				process.MarkReceiptIssued("receipt-1", now)
+			},
+			wantVoid: true,
+		},
+		{
+			name: "after the receipt is voided",
+			steps: func(process *booking.Process) {
+				process.MarkReceiptIssued("receipt-1", now)
+				process.MarkReceiptVoided(now)
+			},
+			wantVoid: false,
+		},
+		{
+			name: "after the booking completed",
+			steps: func(process *booking.Process) {
+				process.MarkReceiptIssued("receipt-1", now)
+				process.MarkTicketPrinted(now)
+				process.MarkNotificationSent(now)
+			},
+			wantVoid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			process := booking.NewProcess("ticket-1", now)
+			tc.steps(process)
+
+			process.Cancel(now.Add(time.Minute))
+
+			assert.Equal(t, booking.StatusCompensated, process.Status)
+			assert.True(t, process.Finished())
+			assert.Equal(t, tc.wantVoid, process.NeedsReceiptVoid())
+		})
+	}
+}
+
+func TestProcess_canceledProcessStaysCompensated(t *testing.T) {
+	process := booking.NewProcess("ticket-1", now)
+	process.Cancel(now)
+
+	// The steps of the canceled booking can still arrive, e.g. the receipt issued meanwhile.
+	process.MarkReceiptIssued("receipt-1", now)
+	process.MarkTicketPrinted(now)
+	process.MarkNotificationSent(now)
+
+	assert.Equal(t, booking.StatusCompensated, process.Status)
+	assert.True(t, process.NeedsReceiptVoid())
+	assert.False(t, process.TimeOut(now))
+}
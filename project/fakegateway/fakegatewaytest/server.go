@@ -0,0 +1,19 @@
+// Package fakegatewaytest starts the fake gateway in tests.
+package fakegatewaytest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"tickets/fakegateway"
+)
+
+// NewServer starts the gateway in a test server, closed when the test finishes.
+func NewServer(t testing.TB) (*fakegateway.Gateway, *httptest.Server) {
+	t.Helper()
+
+	gateway := fakegateway.New()
+	server := httptest.NewServer(gateway.Handler())
+	t.Cleanup(server.Close)
+
+	return gateway, server
+}
@@ -0,0 +1,166 @@
+// Package fakegateway fakes the gateway in front of the receipts, spreadsheets and
+// payments services, so the real adapter clients can be tested without it.
+package fakegateway
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	commonHTTP "github.com/ThreeDotsLabs/go-event-driven/common/http"
+	"github.com/lithammer/shortuuid"
+)
+
+// Service is a service behind the gateway.
+type Service string
+
+const (
+	Receipts     Service = "receipts"
+	Spreadsheets Service = "spreadsheets"
+	Payments     Service = "payments"
+)
+
+// Faults scripts how a service misbehaves.
+type Faults struct {
+	// Latency delays every request.
+	Latency time.Duration
+	// FailNext is the number of next requests answered with FailStatus.
+	FailNext int
+	// FailTickets are the tickets whose requests are always answered with FailStatus.
+	FailTickets []string
+	// FailStatus defaults to 500.
+	FailStatus int
+}
+
+// Gateway keeps the state of the faked services in memory.
+type Gateway struct {
+	mu sync.Mutex
+
+	receipts       []receipts.Receipt
+	receiptsByKey  map[string]receipts.Receipt
+	rows           map[string][][]string
+	refunds        []payments.PaymentRefundRequest
+	faults         map[Service]Faults
+	requests       map[Service]int
+	failedRequests map[Service]int
+}
+
+func New() *Gateway {
+	return &Gateway{
+		receiptsByKey:  map[string]receipts.Receipt{},
+		rows:           map[string][][]string{},
+		faults:         map[Service]Faults{},
+		requests:       map[Service]int{},
+		failedRequests: map[Service]int{},
+	}
+}
+
+// Handler serves the endpoints of the services under their gateway paths, along with
+// the /fake/faults/:service endpoint to script faults in a standalone gateway.
+func (g *Gateway) Handler() http.Handler {
+	e := commonHTTP.NewEcho()
+
+	e.PUT("/receipts-api/receipts", g.issueReceipt)
+	e.GET("/receipts-api/receipts", g.listReceipts)
+	e.PUT("/receipts-api/void-receipt", g.voidReceipt)
+	e.POST("/spreadsheets-api/sheets/:sheet/rows", g.appendRow)
+	e.GET("/spreadsheets-api/sheets/:sheet/rows", g.listRows)
+	e.PUT("/payments-api/refunds", g.refund)
+	e.GET("/payments-api/refunds", g.listRefunds)
+
+	e.PUT("/fake/faults/:service", g.putFaults)
+	e.DELETE("/fake/faults", g.deleteFaults)
+
+	return e
+}
+
+// SetFaults replaces the faults of the service.
+func (g *Gateway) SetFaults(service Service, faults Faults) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.faults[service] = faults
+}
+
+// Reset removes the faults of all the services.
+func (g *Gateway) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.faults = map[Service]Faults{}
+}
+
+// Receipts returns a snapshot of the issued receipts.
+func (g *Gateway) Receipts() []receipts.Receipt {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.receipts)
+}
+
+// Rows returns a snapshot of the rows appended to the sheet.
+func (g *Gateway) Rows(sheet string) [][]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	rows := make([][]string, 0, len(g.rows[sheet]))
+	for _, row := range g.rows[sheet] {
+		rows = append(rows, slices.Clone(row))
+	}
+
+	return rows
+}
+
+// Refunds returns a snapshot of the refunds.
+func (g *Gateway) Refunds() []payments.PaymentRefundRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.refunds)
+}
+
+// Requests returns how many requests the service got, including the failed ones.
+func (g *Gateway) Requests(service Service) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.requests[service]
+}
+
+// FailedRequests returns how many requests of the service failed on purpose.
+func (g *Gateway) FailedRequests(service Service) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.failedRequests[service]
+}
+
+func (g *Gateway) newReceipt(request receipts.CreateReceipt) (receipt receipts.Receipt, duplicated bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := ""
+	if request.IdempotencyKey != nil {
+		key = *request.IdempotencyKey
+	}
+	if original, ok := g.receiptsByKey[key]; ok && key != "" {
+		return original, true
+	}
+
+	receipt = receipts.Receipt{
+		IdempotencyKey: request.IdempotencyKey,
+		IssuedAt:       time.Now().UTC(),
+		Number:         shortuuid.New(),
+		Price:          request.Price,
+		TicketId:       request.TicketId,
+	}
+	g.receipts = append(g.receipts, receipt)
+	if key != "" {
+		g.receiptsByKey[key] = receipt
+	}
+
+	return receipt, false
+}
@@ -0,0 +1,61 @@
+package fakegateway_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"tickets/adapter"
+	"tickets/fakegateway"
+	"tickets/fakegateway/fakegatewaytest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateway(t *testing.T) {
+	ctx := context.Background()
+	gateway, server := fakegatewaytest.NewServer(t)
+
+	config := adapter.DefaultClientsConfig(server.URL)
+	config.Receipts.RetryBaseDelay = time.Millisecond
+	clients := adapter.NewClientsWithConfig(config)
+
+	gateway.SetFaults(fakegateway.Receipts, fakegateway.Faults{
+		FailNext:   2,
+		FailStatus: http.StatusServiceUnavailable,
+	})
+
+	request := adapter.IssueReceiptRequest{
+		TicketID:       "ticket-1",
+		Price:          adapter.Money{Amount: "50.00", Currency: "USD"},
+		IdempotencyKey: "issue-receipt-ticket-1",
+	}
+	issued, err := clients.Receipts.IssueReceipt(ctx, request)
+	require.NoError(t, err)
+
+	duplicated, err := clients.Receipts.IssueReceipt(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, issued.ReceiptNumber, duplicated.ReceiptNumber)
+
+	require.Len(t, gateway.Receipts(), 1)
+	assert.Equal(t, 4, gateway.Requests(fakegateway.Receipts))
+	assert.Equal(t, 2, gateway.FailedRequests(fakegateway.Receipts))
+
+	gateway.SetFaults(fakegateway.Spreadsheets, fakegateway.Faults{
+		FailTickets: []string{"ticket-2"},
+	})
+
+	err = clients.Spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"ticket-1", "50.00"})
+	require.NoError(t, err)
+	err = clients.Spreadsheets.AppendRow(ctx, "tickets-to-print", []string{"ticket-2", "50.00"})
+	assert.Equal(t, adapter.StatusError{StatusCode: http.StatusInternalServerError}, err)
+	assert.Equal(t, [][]string{{"ticket-1", "50.00"}}, gateway.Rows("tickets-to-print"))
+
+	_, err = clients.Payments.RefundPayment(ctx, adapter.RefundPaymentRequest{
+		PaymentReference: "ticket-1",
+		DeduplicationID:  "refund-ticket-1",
+	})
+	require.NoError(t, err)
+	assert.Len(t, gateway.Refunds(), 1)
+}
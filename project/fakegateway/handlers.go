@@ -0,0 +1,200 @@
+package fakegateway
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/spreadsheets"
+	"github.com/labstack/echo/v4"
+)
+
+func (g *Gateway) issueReceipt(c echo.Context) error {
+	var request receipts.CreateReceipt
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	if failed, err := g.misbehave(c, Receipts, request.TicketId); failed {
+		return err
+	}
+
+	receipt, duplicated := g.newReceipt(request)
+	if duplicated {
+		// The receipt was already issued with the same idempotency key.
+		return c.JSON(http.StatusOK, receipt)
+	}
+
+	return c.JSON(http.StatusCreated, receipt)
+}
+
+func (g *Gateway) listReceipts(c echo.Context) error {
+	return c.JSON(http.StatusOK, g.Receipts())
+}
+
+func (g *Gateway) voidReceipt(c echo.Context) error {
+	var request receipts.VoidReceiptRequest
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	if failed, err := g.misbehave(c, Receipts, request.TicketId); failed {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	voided := true
+	for i := range g.receipts {
+		if g.receipts[i].TicketId != request.TicketId {
+			continue
+		}
+		g.receipts[i].Voided = &voided
+		g.receipts[i].VoidReason = &request.Reason
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Gateway) appendRow(c echo.Context) error {
+	var request spreadsheets.PostSheetsSheetRowsJSONRequestBody
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	// Rows have no ticket ID field, so any column matching a failing ticket fails the request.
+	if failed, err := g.misbehave(c, Spreadsheets, request.Columns...); failed {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sheet := c.Param("sheet")
+	g.rows[sheet] = append(g.rows[sheet], slices.Clone(request.Columns))
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Gateway) listRows(c echo.Context) error {
+	return c.JSON(http.StatusOK, spreadsheets.SpreadsheetRows{
+		Rows: g.Rows(c.Param("sheet")),
+	})
+}
+
+func (g *Gateway) refund(c echo.Context) error {
+	var request payments.PaymentRefundRequest
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	if failed, err := g.misbehave(c, Payments, request.PaymentReference); failed {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	duplicated := request.DeduplicationId != nil && slices.ContainsFunc(g.refunds, func(refund payments.PaymentRefundRequest) bool {
+		return refund.DeduplicationId != nil && *refund.DeduplicationId == *request.DeduplicationId
+	})
+	if !duplicated {
+		g.refunds = append(g.refunds, request)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (g *Gateway) listRefunds(c echo.Context) error {
+	return c.JSON(http.StatusOK, g.Refunds())
+}
+
+// faultsRequest is the body of PUT /fake/faults/:service.
+type faultsRequest struct {
+	Latency     string   `json:"latency"`
+	FailNext    int      `json:"fail_next"`
+	FailTickets []string `json:"fail_tickets"`
+	FailStatus  int      `json:"fail_status"`
+}
+
+func (g *Gateway) putFaults(c echo.Context) error {
+	var request faultsRequest
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	service := Service(c.Param("service"))
+	if !slices.Contains([]Service{Receipts, Spreadsheets, Payments}, service) {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown service %q", service))
+	}
+
+	var latency time.Duration
+	if request.Latency != "" {
+		latency, err = time.ParseDuration(request.Latency)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid latency: %v", err))
+		}
+	}
+
+	g.SetFaults(service, Faults{
+		Latency:     latency,
+		FailNext:    request.FailNext,
+		FailTickets: request.FailTickets,
+		FailStatus:  request.FailStatus,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (g *Gateway) deleteFaults(c echo.Context) error {
+	g.Reset()
+	return c.NoContent(http.StatusNoContent)
+}
+
+// misbehave applies the faults of the service to a request about the given tickets.
+// When it fails the request, it has already written the response.
+func (g *Gateway) misbehave(c echo.Context, service Service, ticketIDs ...string) (failed bool, err error) {
+	g.mu.Lock()
+	g.requests[service]++
+	faults := g.faults[service]
+	g.mu.Unlock()
+
+	if faults.Latency > 0 {
+		select {
+		case <-c.Request().Context().Done():
+			return true, c.Request().Context().Err()
+		case <-time.After(faults.Latency):
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	current := g.faults[service]
+	switch {
+	case current.FailNext > 0:
+		current.FailNext--
+		g.faults[service] = current
+	case slices.ContainsFunc(ticketIDs, func(ticketID string) bool {
+		return slices.Contains(current.FailTickets, ticketID)
+	}):
+	default:
+		return false, nil
+	}
+
+	g.failedRequests[service]++
+	status := cmp.Or(current.FailStatus, http.StatusInternalServerError)
+	return true, c.JSON(status, receipts.ErrorResponse{
+		Error: fmt.Sprintf("%s failed on purpose", service),
+	})
+}
@@ -0,0 +1,89 @@
+package loadgen
+
+import (
+	"tickets/adapter"
+	"tickets/domain/ticket"
+	"tickets/fakegateway"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/payments"
+	"github.com/ThreeDotsLabs/go-event-driven/common/clients/receipts"
+)
+
+const (
+	printSheet  = "tickets-to-print"
+	refundSheet = "tickets-to-refund"
+)
+
+// A confirmed ticket is done once its receipt is issued and its row appended.
+// A canceled ticket is done once its payment is refunded and its row appended.
+func done(t ticket.Ticket, receipted, printed, refunded, refundRowed map[string]bool) bool {
+	if t.Status == "canceled" {
+		return refunded[t.ID] && refundRowed[t.ID]
+	}
+
+	return receipted[t.ID] && printed[t.ID]
+}
+
+// GatewayEffects reads the effects from a fake gateway. Each poll copies all the calls
+// the gateway got, so big runs should poll less often.
+type GatewayEffects struct {
+	Gateway *fakegateway.Gateway
+}
+
+func (e GatewayEffects) Done(tickets []ticket.Ticket) []bool {
+	receipted := ticketsOf(e.Gateway.Receipts(), func(receipt receipts.Receipt) string {
+		return receipt.TicketId
+	})
+	refunded := ticketsOf(e.Gateway.Refunds(), func(refund payments.PaymentRefundRequest) string {
+		return refund.PaymentReference
+	})
+	printed := ticketsOf(e.Gateway.Rows(printSheet), firstColumn)
+	refundRowed := ticketsOf(e.Gateway.Rows(refundSheet), firstColumn)
+
+	return doneAll(tickets, receipted, printed, refunded, refundRowed)
+}
+
+// MocksEffects reads the effects from the client mocks of the service. Each poll copies
+// all the calls the mocks got, so big runs should poll less often.
+type MocksEffects struct {
+	Mocks adapter.ClientMocks
+}
+
+func (e MocksEffects) Done(tickets []ticket.Ticket) []bool {
+	receipted := ticketsOf(e.Mocks.Receipts.Issued(), func(receipt adapter.IssueReceiptRequest) string {
+		return receipt.TicketID
+	})
+	refunded := ticketsOf(e.Mocks.Payments.Refunded(), func(refund adapter.RefundPaymentRequest) string {
+		return refund.PaymentReference
+	})
+	printed := ticketsOf(e.Mocks.Spreadsheets.RowsFor(printSheet), firstColumn)
+	refundRowed := ticketsOf(e.Mocks.Spreadsheets.RowsFor(refundSheet), firstColumn)
+
+	return doneAll(tickets, receipted, printed, refunded, refundRowed)
+}
+
+func doneAll(tickets []ticket.Ticket, receipted, printed, refunded, refundRowed map[string]bool) []bool {
+	result := make([]bool, len(tickets))
+	for i, t := range tickets {
+		result[i] = done(t, receipted, printed, refunded, refundRowed)
+	}
+
+	return result
+}
+
+func ticketsOf[T any](items []T, ticketID func(T) string) map[string]bool {
+	ids := make(map[string]bool, len(items))
+	for _, item := range items {
+		ids[ticketID(item)] = true
+	}
+
+	return ids
+}
+
+func firstColumn(row []string) string {
+	if len(row) == 0 {
+		return ""
+	}
+
+	return row[0]
+}
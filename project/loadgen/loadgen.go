@@ -0,0 +1,246 @@
+// Package loadgen posts synthetic tickets to the tickets-status webhook at a target rate
+// and measures how long their downstream effects take.
+package loadgen
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"tickets/adapter"
+	"tickets/domain/ticket"
+	ticketsHTTP "tickets/port/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lithammer/shortuuid"
+)
+
+const (
+	// defaultPollInterval is coarse, since each poll copies all the effects done so far.
+	defaultPollInterval = 100 * time.Millisecond
+	defaultTimeout      = 30 * time.Second
+)
+
+type Config struct {
+	// BaseURL is the address of the tickets API.
+	BaseURL string
+
+	// Rate is the number of requests per second, each with BatchSize tickets.
+	Rate      float64
+	BatchSize int
+	Duration  time.Duration
+
+	// CanceledRatio is the share of canceled tickets, from 0 to 1. The rest are confirmed.
+	CanceledRatio float64
+
+	// Timeout bounds the wait for the effects once all the requests are sent.
+	Timeout time.Duration
+	// PollInterval is how often Effects is checked, so it is the resolution of the latencies.
+	PollInterval time.Duration
+}
+
+// Effects tells which tickets had all their downstream effects done.
+// Done is called every PollInterval with the tickets still pending.
+type Effects interface {
+	Done(tickets []ticket.Ticket) []bool
+}
+
+type sentTicket struct {
+	ticket ticket.Ticket
+	sentAt time.Time
+}
+
+// Run sends the tickets until config.Duration elapses, and waits for their effects.
+// The effects are polled while sending, so the latencies don't include the rest of the run.
+func Run(ctx context.Context, config Config, effects Effects) (Report, error) {
+	if config.Rate <= 0 || config.BatchSize <= 0 || config.Duration <= 0 {
+		return Report{}, fmt.Errorf("rate, batch size and duration must be positive")
+	}
+	interval := time.Duration(float64(time.Second) / config.Rate)
+	if interval <= 0 {
+		return Report{}, fmt.Errorf("rate %g is over one request per nanosecond", config.Rate)
+	}
+	config.Timeout = cmp.Or(config.Timeout, defaultTimeout)
+	config.PollInterval = cmp.Or(config.PollInterval, defaultPollInterval)
+
+	var (
+		mu      sync.Mutex
+		pending = map[string]sentTicket{}
+		report  = Report{Started: time.Now(), PollInterval: config.PollInterval}
+	)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+
+		sendTickets(ctx, config, interval, func(tickets []ticket.Ticket, sentAt time.Time, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Requests++
+			if err != nil {
+				report.RequestErrors++
+				return
+			}
+			report.Tickets += len(tickets)
+			for _, t := range tickets {
+				pending[t.ID] = sentTicket{ticket: t, sentAt: sentAt}
+			}
+		})
+
+		mu.Lock()
+		report.SendDuration = time.Since(report.Started)
+		mu.Unlock()
+	}()
+
+	poll := time.NewTicker(config.PollInterval)
+	defer poll.Stop()
+
+	// timeout is only set once all the requests are sent.
+	var timeout <-chan time.Time
+	sending := sent
+
+	for {
+		select {
+		case <-poll.C:
+		case <-sending:
+			sending = nil
+			timer := time.NewTimer(config.Timeout)
+			defer timer.Stop()
+			timeout = timer.C
+		case <-timeout:
+			return finish(&mu, &report, pending), nil
+		case <-ctx.Done():
+			<-sent
+			return finish(&mu, &report, pending), nil
+		}
+
+		mu.Lock()
+		tickets := make([]ticket.Ticket, 0, len(pending))
+		for _, sent := range pending {
+			tickets = append(tickets, sent.ticket)
+		}
+		mu.Unlock()
+
+		done := effects.Done(tickets)
+		now := time.Now()
+
+		mu.Lock()
+		for i, t := range tickets {
+			if done[i] {
+				report.latencies = append(report.latencies, now.Sub(pending[t.ID].sentAt))
+				delete(pending, t.ID)
+			}
+		}
+		left := len(pending)
+		mu.Unlock()
+
+		if sending == nil && left == 0 {
+			return finish(&mu, &report, pending), nil
+		}
+	}
+}
+
+// sendTickets posts a batch of tickets every interval until config.Duration elapses,
+// and waits for the requests in flight.
+func sendTickets(
+	ctx context.Context,
+	config Config,
+	interval time.Duration,
+	onSent func(tickets []ticket.Ticket, sentAt time.Time, err error),
+) {
+	sendCtx, cancelSend := context.WithTimeout(ctx, config.Duration)
+	defer cancelSend()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-sendCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		tickets := newTickets(config.BatchSize, config.CanceledRatio)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sentAt := time.Now()
+			err := postTicketsStatus(ctx, client, config.BaseURL, tickets)
+			onSent(tickets, sentAt, err)
+		}()
+	}
+}
+
+// finish counts the tickets still pending as timed out.
+func finish(mu *sync.Mutex, report *Report, pending map[string]sentTicket) Report {
+	mu.Lock()
+	defer mu.Unlock()
+
+	report.TimedOut = len(pending)
+	report.Duration = time.Since(report.Started)
+
+	return *report
+}
+
+func newTickets(n int, canceledRatio float64) []ticket.Ticket {
+	tickets := make([]ticket.Ticket, n)
+	for i := range tickets {
+		status := "confirmed"
+		if rand.Float64() < canceledRatio {
+			status = "canceled"
+		}
+
+		tickets[i] = ticket.Ticket{
+			// The ticket IDs are UUIDs, as the receipts repository requires.
+			ID:            uuid.NewString(),
+			Status:        status,
+			CustomerEmail: "loadgen@example.com",
+			Price: ticket.Money{
+				Amount:   "50.00",
+				Currency: "USD",
+			},
+		}
+	}
+
+	return tickets
+}
+
+func postTicketsStatus(ctx context.Context, client *http.Client, baseURL string, tickets []ticket.Ticket) error {
+	payload, err := json.Marshal(ticketsHTTP.TicketsStatusRequest{
+		Tickets: tickets,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/tickets-status", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Correlation-ID", "loadgen_"+shortuuid.New())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return adapter.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
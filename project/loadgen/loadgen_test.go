@@ -0,0 +1,63 @@
+package loadgen_test
+
+import (
+	"context"
+	"testing"
+	"tickets/loadgen"
+	"tickets/service"
+	"tickets/testkit"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	svc := testkit.StartInMemory(t, service.Options{})
+
+	report, err := loadgen.Run(context.Background(), loadgen.Config{
+		BaseURL:       svc.Client.BaseURL(),
+		Rate:          50,
+		BatchSize:     4,
+		Duration:      200 * time.Millisecond,
+		CanceledRatio: 0.5,
+		Timeout:       10 * time.Second,
+	}, loadgen.MocksEffects{Mocks: svc.Mocks})
+	require.NoError(t, err)
+
+	assert.NotZero(t, report.Requests)
+	assert.Zero(t, report.RequestErrors)
+	assert.Equal(t, report.Requests*4, report.Tickets)
+	assert.Equal(t, report.Tickets, report.Completed())
+	assert.Zero(t, report.TimedOut)
+	assert.LessOrEqual(t, report.Percentile(0.5), report.Percentile(0.99))
+	assert.Positive(t, report.Throughput())
+}
+
+func TestRun_reports_timed_out_tickets(t *testing.T) {
+	svc := testkit.StartInMemory(t, service.Options{})
+	svc.Mocks.Receipts.FailNext(1_000_000, nil)
+
+	report, err := loadgen.Run(context.Background(), loadgen.Config{
+		BaseURL:   svc.Client.BaseURL(),
+		Rate:      20,
+		BatchSize: 1,
+		Duration:  100 * time.Millisecond,
+		Timeout:   200 * time.Millisecond,
+	}, loadgen.MocksEffects{Mocks: svc.Mocks})
+	require.NoError(t, err)
+
+	assert.NotZero(t, report.Tickets)
+	assert.Equal(t, report.Tickets, report.TimedOut)
+	assert.Zero(t, report.Completed())
+}
+
+func TestRun_rejects_rate_above_one_request_per_nanosecond(t *testing.T) {
+	_, err := loadgen.Run(context.Background(), loadgen.Config{
+		BaseURL:   "http://localhost:0",
+		Rate:      2e9,
+		BatchSize: 1,
+		Duration:  time.Second,
+	}, loadgen.MocksEffects{})
+	assert.Error(t, err)
+}
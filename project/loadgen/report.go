@@ -0,0 +1,72 @@
+package loadgen
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Report struct {
+	Started      time.Time
+	SendDuration time.Duration
+	// Duration includes the wait for the effects.
+	Duration time.Duration
+
+	Requests      int
+	RequestErrors int
+	Tickets       int
+	// TimedOut is the number of tickets whose effects were not done in time.
+	TimedOut int
+
+	// PollInterval is the resolution of the latencies: a ticket is only seen done
+	// when the effects are polled, so its latency is rounded up to it.
+	PollInterval time.Duration
+	latencies    []time.Duration
+}
+
+// Completed is the number of tickets whose effects were done.
+func (r Report) Completed() int {
+	return len(r.latencies)
+}
+
+// Throughput is the number of completed tickets per second.
+func (r Report) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+
+	return float64(r.Completed()) / r.Duration.Seconds()
+}
+
+// Percentile returns the end-to-end latency under which the given share of tickets, from 0 to 1, completed.
+func (r Report) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(r.latencies)
+	slices.Sort(sorted)
+
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests:   %d sent, %d failed in %s\n", r.Requests, r.RequestErrors, r.SendDuration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "tickets:    %d sent, %d completed, %d timed out\n", r.Tickets, r.Completed(), r.TimedOut)
+	fmt.Fprintf(&b, "throughput: %.1f tickets/s\n", r.Throughput())
+	fmt.Fprintf(
+		&b,
+		"latency:    p50 %s, p90 %s, p99 %s, max %s (polled every %s)",
+		r.Percentile(0.5).Round(time.Millisecond),
+		r.Percentile(0.9).Round(time.Millisecond),
+		r.Percentile(0.99).Round(time.Millisecond),
+		r.Percentile(1).Round(time.Millisecond),
+		r.PollInterval,
+	)
+
+	return b.String()
+}
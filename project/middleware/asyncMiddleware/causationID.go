@@ -0,0 +1,46 @@
+package asyncMiddleware
+
+import (
+	"context"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// CausationIDMetadataKey is used to store the causation ID in metadata.
+// The causation ID of a message is the UUID of the message that caused it to be published.
+const CausationIDMetadataKey = "causation_id"
+
+type causationIDCtxKey struct{}
+
+// ContextWithCausationID returns a context which carries the causation ID for the messages published with it.
+func ContextWithCausationID(ctx context.Context, causationID string) context.Context {
+	return context.WithValue(ctx, causationIDCtxKey{}, causationID)
+}
+
+// CausationIDFromContext returns the causation ID carried by the context, if any.
+func CausationIDFromContext(ctx context.Context) string {
+	causationID, _ := ctx.Value(causationIDCtxKey{}).(string)
+	return causationID
+}
+
+// MessageCausationID returns causation ID from the message.
+func MessageCausationID(msg *message.Message) string {
+	return msg.Metadata.Get(CausationIDMetadataKey)
+}
+
+// CausationID sets the handled message UUID as the causation ID of all messages
+// produced by the handler, both returned and published with the message context.
+func CausationID(h message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		msg.SetContext(ContextWithCausationID(msg.Context(), msg.UUID))
+
+		producedMessages, err := h(msg)
+		for _, produced := range producedMessages {
+			if MessageCausationID(produced) == "" {
+				produced.Metadata.Set(CausationIDMetadataKey, msg.UUID)
+			}
+		}
+
+		return producedMessages, err
+	}
+}
@@ -0,0 +1,272 @@
+package asyncMiddleware
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+const (
+	defaultChaosMaxDelay      = 500 * time.Millisecond
+	defaultChaosReorderWindow = time.Second
+)
+
+// ChaosConfig holds the probabilities, from 0 to 1, of each misbehaviour of the delivery.
+type ChaosConfig struct {
+	// ReplayProbability is the chance of handling a message twice in a row. The copy is
+	// replayed in-process, straight to the same handler, as a redelivery to its consumer
+	// group would be; it is not published again, so the other handlers don't get it.
+	ReplayProbability float64
+
+	// DelayProbability is the chance of waiting up to MaxDelay before handling a message.
+	DelayProbability float64
+	MaxDelay         time.Duration
+
+	// ReorderProbability is the chance of holding a message back, so the next message
+	// of its handler and topic is handled first. A message is held at most ReorderWindow.
+	ReorderProbability float64
+	ReorderWindow      time.Duration
+
+	// Seed makes the misbehaviours reproducible, as long as the messages are handled
+	// in the same order. A random one is used when it is zero.
+	Seed uint64
+}
+
+func (c ChaosConfig) Enabled() bool {
+	return c.ReplayProbability > 0 || c.DelayProbability > 0 || c.ReorderProbability > 0
+}
+
+// Chaos is a router middleware that makes the delivery of the messages worse than the
+// Pub/Sub does, to prove the handlers tolerate at-least-once delivery.
+// It must only be used in test or staging.
+//
+// A held back message blocks its handler until the next message of its key is handled,
+// and is only acked once handled itself. So the messages are only reordered when the
+// handler runs several consumers; with a single one, a held back message is just delayed
+// by ReorderWindow.
+type Chaos struct {
+	config ChaosConfig
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	held   map[string]*heldMessage
+	report map[string]ChaosTopicReport
+}
+
+// heldMessage is closed when the next message of its key is handled.
+type heldMessage struct {
+	overtaken chan struct{}
+}
+
+// ChaosTopicReport counts the misbehaviours applied to the messages of a handler and topic.
+type ChaosTopicReport struct {
+	Handled  int
+	Replayed int
+	Delayed  int
+	// Reordered counts the messages held back for the next one of their key.
+	Reordered int
+}
+
+// ChaosReport is keyed by handler and topic.
+type ChaosReport struct {
+	Seed   uint64
+	Topics map[string]ChaosTopicReport
+}
+
+func NewChaos(config ChaosConfig) *Chaos {
+	config.MaxDelay = cmp.Or(config.MaxDelay, defaultChaosMaxDelay)
+	config.ReorderWindow = cmp.Or(config.ReorderWindow, defaultChaosReorderWindow)
+	if config.Seed == 0 {
+		config.Seed = rand.Uint64()
+	}
+
+	return &Chaos{
+		config: config,
+		rand:   rand.New(rand.NewPCG(config.Seed, config.Seed)),
+		held:   map[string]*heldMessage{},
+		report: map[string]ChaosTopicReport{},
+	}
+}
+
+func (c *Chaos) Middleware(next message.HandlerFunc) message.HandlerFunc {
+	return func(msg *message.Message) ([]*message.Message, error) {
+		key := message.HandlerNameFromCtx(msg.Context()) + ":" + message.SubscribeTopicFromCtx(msg.Context())
+		c.count(key, func(report *ChaosTopicReport) {
+			report.Handled++
+		})
+
+		if delay, ok := c.delay(); ok {
+			c.count(key, func(report *ChaosTopicReport) {
+				report.Delayed++
+			})
+
+			select {
+			case <-msg.Context().Done():
+				return nil, msg.Context().Err()
+			case <-time.After(delay):
+			}
+		}
+
+		if held, ok := c.hold(key); ok {
+			select {
+			case <-held.overtaken:
+			case <-time.After(c.config.ReorderWindow):
+				c.unhold(key, held)
+			case <-msg.Context().Done():
+				c.unhold(key, held)
+				return nil, msg.Context().Err()
+			}
+		}
+
+		produced, err := next(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.roll(c.config.ReplayProbability) {
+			c.count(key, func(report *ChaosTopicReport) {
+				report.Replayed++
+			})
+
+			replayed, err := next(replay(msg))
+			if err != nil {
+				return nil, err
+			}
+			produced = append(produced, replayed...)
+		}
+
+		c.overtake(key)
+
+		return produced, nil
+	}
+}
+
+// Report returns the misbehaviours applied so far.
+func (c *Chaos) Report() ChaosReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	topics := make(map[string]ChaosTopicReport, len(c.report))
+	for key, report := range c.report {
+		topics[key] = report
+	}
+
+	return ChaosReport{
+		Seed:   c.config.Seed,
+		Topics: topics,
+	}
+}
+
+func (r ChaosReport) String() string {
+	keys := make([]string, 0, len(r.Topics))
+	for key := range r.Topics {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "chaos report (seed %d):", r.Seed)
+	for _, key := range keys {
+		report := r.Topics[key]
+		fmt.Fprintf(
+			&b,
+			"\n  %s: handled %d, replayed %d, delayed %d, reordered %d",
+			key, report.Handled, report.Replayed, report.Delayed, report.Reordered,
+		)
+	}
+
+	return b.String()
+}
+
+// replay copies the message for a second handling, so the handler can't tell it from
+// a redelivery of the same message.
+func replay(msg *message.Message) *message.Message {
+	replayed := msg.Copy()
+	replayed.SetContext(msg.Context())
+
+	return replayed
+}
+
+// hold keeps the message to be handled after the next one of its key, unless
+// another message of the key is already held.
+func (c *Chaos) hold(key string) (*heldMessage, bool) {
+	if !c.roll(c.config.ReorderProbability) {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.held[key]; ok {
+		return nil, false
+	}
+
+	held := &heldMessage{
+		overtaken: make(chan struct{}),
+	}
+	c.held[key] = held
+
+	report := c.report[key]
+	report.Reordered++
+	c.report[key] = report
+
+	return held, true
+}
+
+// unhold stops holding the message once its window is over.
+func (c *Chaos) unhold(key string, held *heldMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.held[key] == held {
+		delete(c.held, key)
+	}
+}
+
+// overtake lets the held message of the key, if any, be handled.
+func (c *Chaos) overtake(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if held, ok := c.held[key]; ok {
+		close(held.overtaken)
+		delete(c.held, key)
+	}
+}
+
+func (c *Chaos) delay() (time.Duration, bool) {
+	if !c.roll(c.config.DelayProbability) {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Duration(c.rand.Int64N(int64(c.config.MaxDelay))), true
+}
+
+func (c *Chaos) roll(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rand.Float64() < probability
+}
+
+func (c *Chaos) count(key string, update func(report *ChaosTopicReport)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.report[key]
+	update(&report)
+	c.report[key] = report
+}
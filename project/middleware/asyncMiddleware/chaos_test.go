@@ -0,0 +1,208 @@
+package asyncMiddleware_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"tickets/middleware/asyncMiddleware"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaos_reorder(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReorderProbability: 1,
+		ReorderWindow:      time.Hour,
+		Seed:               1,
+	})
+
+	var mu sync.Mutex
+	var handled []string
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		handled = append(handled, msg.UUID)
+		return []*message.Message{message.NewMessage("produced-"+msg.UUID, nil)}, nil
+	})
+
+	first := handleAsync(handler, message.NewMessage("1", nil))
+	require.Eventually(t, func() bool {
+		return chaos.Report().Topics[":"].Reordered == 1
+	}, time.Second, time.Millisecond)
+
+	produced, err := handler(message.NewMessage("2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "produced-2", produced[0].UUID)
+
+	result := <-first
+	require.NoError(t, result.err)
+	// The messages produced by the held back message are forwarded.
+	require.Len(t, result.produced, 1)
+	assert.Equal(t, "produced-1", result.produced[0].UUID)
+
+	assert.Equal(t, []string{"2", "1"}, handled)
+
+	report := chaos.Report()
+	assert.EqualValues(t, 1, report.Seed)
+	assert.Equal(t, asyncMiddleware.ChaosTopicReport{Handled: 2, Reordered: 1}, report.Topics[":"])
+}
+
+func TestChaos_reorderWindow(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReorderProbability: 1,
+		ReorderWindow:      10 * time.Millisecond,
+	})
+
+	calls := 0
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, nil
+	})
+
+	// No message comes next, so the held back one is handled once its window is over.
+	_, err := handler(message.NewMessage("1", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, chaos.Report().Topics[":"].Reordered)
+}
+
+func TestChaos_reorderFailure(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReorderProbability: 1,
+		ReorderWindow:      time.Hour,
+	})
+
+	handlerErr := errors.New("handler failed")
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		if msg.UUID == "1" {
+			return nil, handlerErr
+		}
+		return nil, nil
+	})
+
+	first := handleAsync(handler, message.NewMessage("1", nil))
+	require.Eventually(t, func() bool {
+		return chaos.Report().Topics[":"].Reordered == 1
+	}, time.Second, time.Millisecond)
+
+	_, err := handler(message.NewMessage("2", nil))
+	require.NoError(t, err)
+
+	// The error reaches the router, so the held back message is nacked instead of lost.
+	result := <-first
+	assert.ErrorIs(t, result.err, handlerErr)
+}
+
+func TestChaos_reorderCanceled(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReorderProbability: 1,
+		ReorderWindow:      time.Hour,
+	})
+
+	calls := 0
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msg := message.NewMessage("1", nil)
+	msg.SetContext(ctx)
+
+	first := handleAsync(handler, msg)
+	require.Eventually(t, func() bool {
+		return chaos.Report().Topics[":"].Reordered == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	result := <-first
+	assert.ErrorIs(t, result.err, context.Canceled)
+	assert.Zero(t, calls)
+}
+
+func TestChaos_delay(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		DelayProbability: 1,
+		MaxDelay:         10 * time.Millisecond,
+	})
+
+	calls := 0
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, nil
+	})
+
+	_, err := handler(message.NewMessage("1", nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, asyncMiddleware.ChaosTopicReport{Handled: 1, Delayed: 1}, chaos.Report().Topics[":"])
+}
+
+func TestChaos_delayCanceled(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		DelayProbability: 1,
+		MaxDelay:         time.Hour,
+	})
+
+	calls := 0
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		calls++
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	msg := message.NewMessage("1", nil)
+	msg.SetContext(ctx)
+
+	_, err := handler(msg)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, calls)
+}
+
+type handleResult struct {
+	produced []*message.Message
+	err      error
+}
+
+func handleAsync(handler message.HandlerFunc, msg *message.Message) <-chan handleResult {
+	result := make(chan handleResult, 1)
+	go func() {
+		produced, err := handler(msg)
+		result <- handleResult{produced: produced, err: err}
+	}()
+
+	return result
+}
+
+func TestChaos_replay(t *testing.T) {
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReplayProbability: 1,
+	})
+
+	var handled []*message.Message
+	handler := chaos.Middleware(func(msg *message.Message) ([]*message.Message, error) {
+		handled = append(handled, msg)
+		return nil, nil
+	})
+
+	msg := message.NewMessage("1", []byte(`{"ticket_id":"ticket-1"}`))
+	msg.Metadata.Set("correlation_id", "correlation-1")
+	_, err := handler(msg)
+	require.NoError(t, err)
+
+	// The replay is a copy of the message, as a redelivery would be.
+	require.Len(t, handled, 2)
+	assert.Same(t, msg, handled[0])
+	assert.NotSame(t, msg, handled[1])
+	assert.True(t, msg.Equals(handled[1]))
+	assert.Equal(t, msg.Context(), handled[1].Context())
+	assert.Equal(t, 1, chaos.Report().Topics[":"].Replayed)
+}
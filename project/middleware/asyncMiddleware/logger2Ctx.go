@@ -8,7 +8,10 @@ import (
 
 func Logger2Context(next message.HandlerFunc) message.HandlerFunc {
 	return func(msg *message.Message) ([]*message.Message, error) {
-		ctx := log.ToContext(msg.Context(), logrus.WithFields(logrus.Fields{"correlation_id": MessageCorrelationID(msg)}))
+		ctx := log.ToContext(msg.Context(), logrus.WithFields(logrus.Fields{
+			"correlation_id": MessageCorrelationID(msg),
+			"causation_id":   MessageCausationID(msg),
+		}))
 		msg.SetContext(ctx)
 		return next(msg)
 	}
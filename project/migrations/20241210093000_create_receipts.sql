@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS receipts (
+	ticket_id UUID PRIMARY KEY,
+	receipt_number VARCHAR(255) NOT NULL,
+	issued_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS receipts;
+-- +goose StatementEnd
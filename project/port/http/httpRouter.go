@@ -1,58 +1,94 @@
 package http
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"tickets/adapter"
-	"tickets/decorator"
 	"tickets/domain/ticket"
 	"tickets/middleware/httpMiddleware"
 
 	commonHTTP "github.com/ThreeDotsLabs/go-event-driven/common/http"
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill"
-	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
 	"github.com/labstack/echo/v4"
-	"github.com/lithammer/shortuuid/v3"
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
+const defaultAddr = ":8080"
+
 type TicketsStatusRequest struct {
 	Tickets []ticket.Ticket `json:"tickets"`
 }
 
 type HTTPRouterRunner struct {
-	ctx    context.Context
-	rdb    *redis.Client
-	logger watermill.LoggerAdapter
-	g      *errgroup.Group
+	ctx          context.Context
+	publisher    message.Publisher
+	repositories adapter.Repositories
+	logger       watermill.LoggerAdapter
+	g            *errgroup.Group
+	ids          adapter.IDGenerator
+
+	addr      string
+	listener  net.Listener
+	listening chan struct{}
 }
 
 type NewHTTPRouterRunnerInfo struct {
-	Ctx    context.Context
-	RDB    *redis.Client
-	Logger watermill.LoggerAdapter
-	G      *errgroup.Group
+	Ctx          context.Context
+	Publisher    message.Publisher
+	Repositories adapter.Repositories
+	Logger       watermill.LoggerAdapter
+	G            *errgroup.Group
+
+	// Addr is optional. It defaults to ":8080". Use ":0" to listen on a random port.
+	Addr string
+
+	// IDs is optional. It generates the correlation IDs of the requests without one,
+	// and the UUIDs of the published messages. It defaults to random short UUIDs.
+	IDs adapter.IDGenerator
 }
 
 func NewHTTPRouterRunner(info NewHTTPRouterRunnerInfo) *HTTPRouterRunner {
+	var ids adapter.IDGenerator = adapter.ShortUUIDGenerator{}
+	if info.IDs != nil {
+		ids = info.IDs
+	}
+
 	return &HTTPRouterRunner{
-		ctx:    info.Ctx,
-		rdb:    info.RDB,
-		logger: info.Logger,
-		g:      info.G,
+		ctx:          info.Ctx,
+		publisher:    info.Publisher,
+		repositories: info.Repositories,
+		logger:       info.Logger,
+		g:            info.G,
+		ids:          ids,
+
+		addr:      cmp.Or(info.Addr, defaultAddr),
+		listening: make(chan struct{}),
 	}
 }
 
+// Listening is closed once the server accepts connections.
+func (hrr *HTTPRouterRunner) Listening() <-chan struct{} {
+	return hrr.listening
+}
+
+// Addr returns the address the server listens on. It is only set once Listening is closed.
+func (hrr *HTTPRouterRunner) Addr() net.Addr {
+	return hrr.listener.Addr()
+}
+
 func (hrr *HTTPRouterRunner) RunAsync() {
 	e := commonHTTP.NewEcho()
 	e.Use(httpMiddleware.RequestIDWithConfig(httpMiddleware.RequestIDConfig{
 		TargetHeader: "Correlation-Id",
 		Generator: func() string {
-			return shortuuid.New()
+			return hrr.ids.NewID(context.Background())
 		},
 		// This will set the CorrelationID in the context
 		RequestIDHandler: func(c echo.Context, id string) {
@@ -60,14 +96,7 @@ func (hrr *HTTPRouterRunner) RunAsync() {
 		},
 	}))
 
-	publisher, err := redisstream.NewPublisher(redisstream.PublisherConfig{
-		Client: hrr.rdb,
-	}, hrr.logger)
-	if err != nil {
-		panic(fmt.Errorf("unable to create publisher: %w", err))
-	}
-
-	eventBus, err := adapter.NewEventBus(decorator.DecorateWithCorrelationPublisherDecorator(publisher))
+	eventBus, err := adapter.NewEventBus(hrr.publisher, hrr.ids)
 	if err != nil {
 		panic(fmt.Errorf("unable to create event bus: %w", err))
 	}
@@ -108,14 +137,33 @@ func (hrr *HTTPRouterRunner) RunAsync() {
 		return c.NoContent(http.StatusOK)
 	})
 
+	e.GET("/tickets/:id/receipt", func(c echo.Context) error {
+		receipt, err := hrr.repositories.Receipts.FindByTicketID(c.Request().Context(), c.Param("id"))
+		if errors.Is(err, adapter.ErrReceiptNotFound) {
+			return c.String(http.StatusNotFound, "Receipt not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, receipt)
+	})
+
 	e.GET("/health", func(c echo.Context) error {
 		return c.String(http.StatusOK, "ok")
 	})
 
 	logrus.Info("Server starting...")
 
+	hrr.listener, err = net.Listen("tcp", hrr.addr)
+	if err != nil {
+		panic(fmt.Errorf("unable to listen on %s: %w", hrr.addr, err))
+	}
+	e.Listener = hrr.listener
+	close(hrr.listening)
+
 	hrr.g.Go(func() error {
-		err := e.Start(":8080")
+		err := e.Start(hrr.addr)
 		if err != nil && err != http.ErrServerClosed {
 			return err
 		}
@@ -0,0 +1,174 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"tickets/adapter"
+	"tickets/domain/booking"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+)
+
+const defaultBookingTimeout = 10 * time.Minute
+
+// The booking process manager tracks each booking across its steps (receipt issued,
+// ticket printed, notification sent) and reacts when the booking is canceled
+// or gets stuck. It doesn't start the steps: each one is started by its own handler
+// of TicketBookingConfirmed, and the manager follows them through their outcome events.
+// The only command it issues is the compensation of a canceled booking, which voids
+// the issued receipt; the refund row is appended by refundTicketHandler.
+// A stuck booking is reported with a TicketBookingTimedOut event, listing its missing steps.
+
+func (mrr *MessageRouterRunner) bookingProcessStartHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessStartHandler",
+		func(ctx context.Context, event *adapter.TicketBookingConfirmed) error {
+			// Starts the process, unless another step already did it.
+			err := mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.eventBus.Publish(
+				adapter.ContextWithDelay(ctx, mrr.bookingTimeout),
+				adapter.TicketBookingTimeoutElapsed{
+					TicketID: event.TicketID,
+				},
+			)
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessReceiptIssuedHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessReceiptIssuedHandler",
+		func(ctx context.Context, event *adapter.TicketReceiptIssued) error {
+			var needsVoid bool
+			err := mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				process.MarkReceiptIssued(event.ReceiptNumber, mrr.clock.Now())
+				needsVoid = process.NeedsReceiptVoid()
+				return nil
+			})
+			if err != nil || !needsVoid {
+				return err
+			}
+
+			// The booking was canceled before the receipt was issued.
+			return mrr.voidBookingReceipt(ctx, event.TicketID)
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessTicketPrintedHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessTicketPrintedHandler",
+		func(ctx context.Context, event *adapter.TicketPrinted) error {
+			return mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				process.MarkTicketPrinted(mrr.clock.Now())
+				return nil
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessNotificationSentHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessNotificationSentHandler",
+		func(ctx context.Context, event *adapter.TicketNotificationSent) error {
+			if event.Notification != bookingConfirmationNotification {
+				return nil
+			}
+
+			return mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				process.MarkNotificationSent(mrr.clock.Now())
+				return nil
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessTimeoutHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessTimeoutHandler",
+		func(ctx context.Context, event *adapter.TicketBookingTimeoutElapsed) error {
+			var timedOut bool
+			var process booking.Process
+			err := mrr.bookingProcesses.Update(ctx, event.TicketID, func(p *booking.Process) error {
+				timedOut = p.TimeOut(mrr.clock.Now())
+				process = *p
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if timedOut {
+				log.FromContext(ctx).
+					WithField("ticket_id", process.TicketID).
+					WithField("receipt_issued", process.ReceiptIssued).
+					WithField("ticket_printed", process.TicketPrinted).
+					WithField("notification_sent", process.NotificationSent).
+					Warn("Booking process timed out")
+			}
+
+			// Published on redeliveries too, in case the process was updated but the event was not published.
+			if process.Status != booking.StatusTimedOut {
+				return nil
+			}
+
+			return mrr.eventBus.Publish(ctx, adapter.TicketBookingTimedOut{
+				TicketID:         process.TicketID,
+				ReceiptIssued:    process.ReceiptIssued,
+				TicketPrinted:    process.TicketPrinted,
+				NotificationSent: process.NotificationSent,
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessCancelHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessCancelHandler",
+		func(ctx context.Context, event *adapter.TicketBookingCanceled) error {
+			var needsVoid bool
+			err := mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				process.Cancel(mrr.clock.Now())
+				needsVoid = process.NeedsReceiptVoid()
+				return nil
+			})
+			if err != nil || !needsVoid {
+				return err
+			}
+
+			return mrr.voidBookingReceipt(ctx, event.TicketID)
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) bookingProcessReceiptVoidedHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"bookingProcessReceiptVoidedHandler",
+		func(ctx context.Context, event *adapter.TicketReceiptVoided) error {
+			return mrr.bookingProcesses.Update(ctx, event.TicketID, func(process *booking.Process) error {
+				process.MarkReceiptVoided(mrr.clock.Now())
+				return nil
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) voidBookingReceipt(ctx context.Context, ticketID string) error {
+	err := mrr.commandBus.Send(ctx, adapter.VoidReceipt{
+		TicketID: ticketID,
+		Reason:   "ticket booking canceled",
+	})
+	if err != nil {
+		return fmt.Errorf("unable to request the void of receipt of ticket %s: %w", ticketID, err)
+	}
+
+	return nil
+}
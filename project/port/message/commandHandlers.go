@@ -0,0 +1,69 @@
+package message
+
+import (
+	"context"
+	"tickets/adapter"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+)
+
+func (mrr *MessageRouterRunner) issueReceiptCommandHandler() cqrs.CommandHandler {
+	return cqrs.NewCommandHandler(
+		"issueReceiptCommandHandler",
+		func(ctx context.Context, command *adapter.IssueReceipt) error {
+			receipt, err := mrr.clients.Receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{
+				TicketID: command.TicketID,
+				Price: adapter.Money{
+					Amount:   command.Price.Amount,
+					Currency: command.Price.Currency,
+				},
+				IdempotencyKey: command.IdempotencyKey,
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.eventBus.Publish(ctx, adapter.TicketReceiptIssued{
+				TicketID:      command.TicketID,
+				ReceiptNumber: receipt.ReceiptNumber,
+				IssuedAt:      receipt.IssuedAt,
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) voidReceiptCommandHandler() cqrs.CommandHandler {
+	return cqrs.NewCommandHandler(
+		"voidReceiptCommandHandler",
+		func(ctx context.Context, command *adapter.VoidReceipt) error {
+			err := mrr.clients.Receipts.VoidReceipt(ctx, adapter.VoidReceiptRequest{
+				TicketID: command.TicketID,
+				Reason:   command.Reason,
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.eventBus.Publish(ctx, adapter.TicketReceiptVoided{
+				TicketID: command.TicketID,
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) appendTicketRowCommandHandler() cqrs.CommandHandler {
+	return cqrs.NewCommandHandler(
+		"appendTicketRowCommandHandler",
+		func(ctx context.Context, command *adapter.AppendTicketRow) error {
+			if command.EventType == "" {
+				return mrr.clients.Spreadsheets.AppendRow(ctx, command.SheetName, command.Row)
+			}
+
+			return mrr.spreadsheets.AppendRowOnce(ctx, adapter.RowKey{
+				Sheet:     command.SheetName,
+				TicketID:  command.TicketID,
+				EventType: command.EventType,
+			}, command.Row)
+		},
+	)
+}
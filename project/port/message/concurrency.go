@@ -0,0 +1,192 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+const serialTicketOption = "serial"
+
+// HandlerConcurrency defines how many messages a single handler can process at the same time.
+type HandlerConcurrency struct {
+	// Consumers is the number of consumers that the handler joins to its consumer group.
+	// Each consumer holds at most one in-flight message, so it also bounds the in-flight messages.
+	// Values lower than one are treated as one.
+	Consumers int
+
+	// SerializeByTicket ensures that messages with the same ticket ID are never
+	// processed concurrently by the handler, even when using several consumers.
+	SerializeByTicket bool
+}
+
+// HandlersConcurrency maps handler names to its concurrency settings.
+// Handlers not present in the map process one message at a time.
+type HandlersConcurrency map[string]HandlerConcurrency
+
+func (hc HandlersConcurrency) For(handlerName string) HandlerConcurrency {
+	concurrency := hc[handlerName]
+	concurrency.Consumers = max(concurrency.Consumers, 1)
+	return concurrency
+}
+
+// ParseHandlersConcurrency parses a comma separated list of handler settings with the
+// form name=consumers[:serial], like "printTicketHandler=4,issueReceiptHandler=2:serial".
+func ParseHandlersConcurrency(s string) (HandlersConcurrency, error) {
+	hc := HandlersConcurrency{}
+	if strings.TrimSpace(s) == "" {
+		return hc, nil
+	}
+
+	for _, setting := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid handler concurrency setting %q", setting)
+		}
+
+		consumers, option, _ := strings.Cut(value, ":")
+		n, err := strconv.Atoi(consumers)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid consumers number for handler %s: %q", name, consumers)
+		}
+
+		if option != "" && option != serialTicketOption {
+			return nil, fmt.Errorf("unknown concurrency option for handler %s: %q", name, option)
+		}
+
+		hc[name] = HandlerConcurrency{
+			Consumers:         n,
+			SerializeByTicket: option == serialTicketOption,
+		}
+	}
+
+	return hc, nil
+}
+
+// pooledSubscriber joins several subscribers to the same topic and merges their messages.
+// When all the subscribers share a consumer group, the messages are spread among them.
+type pooledSubscriber struct {
+	subscribers []message.Subscriber
+}
+
+func newPooledSubscriber(size int, constructor func() (message.Subscriber, error)) (message.Subscriber, error) {
+	if size <= 1 {
+		return constructor()
+	}
+
+	pool := &pooledSubscriber{}
+	for range size {
+		sub, err := constructor()
+		if err != nil {
+			return nil, errors.Join(err, pool.Close())
+		}
+
+		pool.subscribers = append(pool.subscribers, sub)
+	}
+
+	return pool, nil
+}
+
+// Subscribe subscribes every subscriber to the topic. When one of them fails, the
+// subscriptions already started are stopped before returning the error.
+func (p *pooledSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan *message.Message)
+	wg := sync.WaitGroup{}
+
+	for _, sub := range p.subscribers {
+		messages, err := sub.Subscribe(ctx, topic)
+		if err != nil {
+			cancel()
+			wg.Wait()
+			return nil, err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range messages {
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					msg.Nack()
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+func (p *pooledSubscriber) Close() error {
+	var errs []error
+	for _, sub := range p.subscribers {
+		errs = append(errs, sub.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// keyedMutex provides a lock for each key, releasing the memory of keys not in use.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{
+		locks: map[string]*keyedMutexEntry{},
+	}
+}
+
+// Lock locks the given key and returns the function that unlocks it.
+func (km *keyedMutex) Lock(key string) (unlock func()) {
+	km.mu.Lock()
+	entry, ok := km.locks[key]
+	if !ok {
+		entry = &keyedMutexEntry{}
+		km.locks[key] = entry
+	}
+	entry.waiters++
+	km.mu.Unlock()
+
+	entry.mu.Lock()
+
+	return func() {
+		entry.mu.Unlock()
+
+		km.mu.Lock()
+		entry.waiters--
+		if entry.waiters == 0 {
+			delete(km.locks, key)
+		}
+		km.mu.Unlock()
+	}
+}
+
+// messageTicketID extracts the ticket ID from the message payload, if any.
+func messageTicketID(msg *message.Message) string {
+	var payload struct {
+		TicketID string `json:"ticket_id"`
+	}
+	_ = json.Unmarshal(msg.Payload, &payload)
+
+	return payload.TicketID
+}
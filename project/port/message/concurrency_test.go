@@ -0,0 +1,200 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tickets/port/message"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHandlersConcurrency(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setting  string
+		expected message.HandlersConcurrency
+	}{
+		{
+			name:     "empty",
+			setting:  " ",
+			expected: message.HandlersConcurrency{},
+		},
+		{
+			name:    "consumers",
+			setting: "printTicketHandler=4",
+			expected: message.HandlersConcurrency{
+				"printTicketHandler": {Consumers: 4},
+			},
+		},
+		{
+			name:    "several handlers",
+			setting: "printTicketHandler=4, issueReceiptHandler=2:serial",
+			expected: message.HandlersConcurrency{
+				"printTicketHandler":  {Consumers: 4},
+				"issueReceiptHandler": {Consumers: 2, SerializeByTicket: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			concurrency, err := message.ParseHandlersConcurrency(tc.setting)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, concurrency)
+		})
+	}
+}
+
+func TestParseHandlersConcurrency_invalid(t *testing.T) {
+	for _, setting := range []string{
+		"printTicketHandler",
+		"=4",
+		"printTicketHandler=four",
+		"printTicketHandler=0",
+		"printTicketHandler=4:unknown",
+	} {
+		t.Run(setting, func(t *testing.T) {
+			_, err := message.ParseHandlersConcurrency(setting)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandlersConcurrency_For(t *testing.T) {
+	concurrency := message.HandlersConcurrency{
+		"printTicketHandler":  {Consumers: 4, SerializeByTicket: true},
+		"issueReceiptHandler": {Consumers: 0},
+	}
+
+	assert.Equal(t, message.HandlerConcurrency{Consumers: 4, SerializeByTicket: true}, concurrency.For("printTicketHandler"))
+	assert.Equal(t, message.HandlerConcurrency{Consumers: 1}, concurrency.For("issueReceiptHandler"))
+	assert.Equal(t, message.HandlerConcurrency{Consumers: 1}, concurrency.For("unknownHandler"))
+}
+
+func TestPooledSubscriber(t *testing.T) {
+	pubSubs := []*gochannel.GoChannel{
+		gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}),
+		gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{}),
+	}
+	created := 0
+	pool, err := message.NewPooledSubscriber(2, func() (watermillMessage.Subscriber, error) {
+		created++
+		return pubSubs[created-1], nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, err := pool.Subscribe(ctx, "topic")
+	require.NoError(t, err)
+
+	require.NoError(t, pubSubs[0].Publish("topic", watermillMessage.NewMessage("1", nil)))
+	require.NoError(t, pubSubs[1].Publish("topic", watermillMessage.NewMessage("2", nil)))
+
+	var received []string
+	for range 2 {
+		select {
+		case msg := <-messages:
+			msg.Ack()
+			received = append(received, msg.UUID)
+		case <-time.After(time.Second):
+			require.FailNow(t, "message not received")
+		}
+	}
+	assert.ElementsMatch(t, []string{"1", "2"}, received)
+
+	cancel()
+	assertClosed(t, messages)
+}
+
+func TestPooledSubscriber_stopsStartedSubscriptionsOnFailure(t *testing.T) {
+	subscribeErr := errors.New("unable to subscribe")
+	started := &stubSubscriber{stopped: make(chan struct{})}
+	subscribers := []*stubSubscriber{started, {err: subscribeErr}}
+
+	created := 0
+	pool, err := message.NewPooledSubscriber(2, func() (watermillMessage.Subscriber, error) {
+		created++
+		return subscribers[created-1], nil
+	})
+	require.NoError(t, err)
+
+	_, err = pool.Subscribe(context.Background(), "topic")
+	assert.ErrorIs(t, err, subscribeErr)
+
+	select {
+	case <-started.stopped:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the started subscription was not stopped")
+	}
+}
+
+func TestKeyedMutex(t *testing.T) {
+	km := message.NewKeyedMutex()
+
+	unlock := km.Lock("ticket-1")
+
+	locked := make(chan func())
+	go func() {
+		locked <- km.Lock("ticket-1")
+	}()
+
+	// Other keys are not blocked.
+	km.Lock("ticket-2")()
+
+	select {
+	case <-locked:
+		require.FailNow(t, "the key was locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case unlockSecond := <-locked:
+		unlockSecond()
+	case <-time.After(time.Second):
+		require.FailNow(t, "the key was not unlocked")
+	}
+}
+
+// stubSubscriber fails to subscribe with err, or serves a subscription without messages
+// that is stopped with its context.
+type stubSubscriber struct {
+	err     error
+	stopped chan struct{}
+}
+
+func (s *stubSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *watermillMessage.Message, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	messages := make(chan *watermillMessage.Message)
+	go func() {
+		<-ctx.Done()
+		close(messages)
+		close(s.stopped)
+	}()
+
+	return messages, nil
+}
+
+func (s *stubSubscriber) Close() error {
+	return nil
+}
+
+func assertClosed(t *testing.T, messages <-chan *watermillMessage.Message) {
+	t.Helper()
+
+	select {
+	case _, ok := <-messages:
+		assert.False(t, ok, "unexpected message")
+	case <-time.After(time.Second):
+		assert.Fail(t, "the subscription was not closed")
+	}
+}
@@ -0,0 +1,7 @@
+package message
+
+// Exported for the tests of the message package.
+var (
+	NewPooledSubscriber = newPooledSubscriber
+	NewKeyedMutex       = newKeyedMutex
+)
@@ -3,8 +3,11 @@ package message
 import (
 	"cmp"
 	"context"
+	"errors"
 	"tickets/adapter"
+	"tickets/render"
 
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill/components/cqrs"
 )
 
@@ -12,32 +15,72 @@ func (mrr *MessageRouterRunner) issueReceiptHandler() cqrs.EventHandler {
 	return cqrs.NewEventHandler(
 		"issueReceiptHandler",
 		func(ctx context.Context, event *adapter.TicketBookingConfirmed) error {
-			_, err := mrr.clients.Receipts.IssueReceipt(ctx, adapter.IssueReceiptRequest{
-				TicketID: event.TicketID,
-				Price: adapter.Money{
-					Amount:   event.Price.Amount,
-					Currency: event.Price.Currency,
-				},
+			return mrr.commandBus.Send(ctx, adapter.IssueReceipt{
+				TicketID:       event.TicketID,
+				Price:          event.Price,
+				IdempotencyKey: receiptIdempotencyKey(ctx, event.TicketID),
 			})
-			return err
 		},
 	)
 }
 
+// receiptIdempotencyKey derives the key from the ticket and the handled event,
+// so redeliveries of the event never issue a second receipt.
+func receiptIdempotencyKey(ctx context.Context, ticketID string) string {
+	key := "issue-receipt-" + ticketID
+	if msg := cqrs.OriginalMessageFromCtx(ctx); msg != nil {
+		key += "-" + msg.UUID
+	}
+
+	return key
+}
+
 func (mrr *MessageRouterRunner) printTicketHandler() cqrs.EventHandler {
 	return cqrs.NewEventHandler(
 		"printTicketHandler",
 		func(ctx context.Context, event *adapter.TicketBookingConfirmed) error {
-			return mrr.clients.Spreadsheets.AppendRow(
-				ctx,
-				"tickets-to-print",
-				[]string{
-					event.TicketID,
-					event.CustomerEmail,
-					event.Price.Amount,
-					cmp.Or(event.Price.Currency, "USD"),
-				},
-			)
+			return mrr.appendSheetRows(ctx, event.TicketID, *event)
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) renderTicketHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"renderTicketHandler",
+		func(ctx context.Context, event *adapter.TicketBookingConfirmed) error {
+			document := render.TicketDocument{
+				TicketID:      event.TicketID,
+				CustomerEmail: event.CustomerEmail,
+				PriceAmount:   event.Price.Amount,
+				PriceCurrency: cmp.Or(event.Price.Currency, "USD"),
+			}
+
+			html, err := render.TicketHTML(document)
+			if err != nil {
+				return err
+			}
+
+			pdf, err := render.TicketPDF(document)
+			if err != nil {
+				return err
+			}
+
+			// The file names only depend on the ticket, so a redelivery replaces the same files.
+			htmlFileName, err := mrr.clients.Files.Save(ctx, event.TicketID+"-ticket.html", html)
+			if err != nil {
+				return err
+			}
+
+			pdfFileName, err := mrr.clients.Files.Save(ctx, event.TicketID+"-ticket.pdf", pdf)
+			if err != nil {
+				return err
+			}
+
+			return mrr.eventBus.Publish(ctx, adapter.TicketPrinted{
+				TicketID:     event.TicketID,
+				FileName:     pdfFileName,
+				HTMLFileName: htmlFileName,
+			})
 		},
 	)
 }
@@ -46,16 +89,72 @@ func (mrr *MessageRouterRunner) refundTicketHandler() cqrs.EventHandler {
 	return cqrs.NewEventHandler(
 		"refundTicketHandler",
 		func(ctx context.Context, event *adapter.TicketBookingCanceled) error {
-			return mrr.clients.Spreadsheets.AppendRow(
-				ctx,
-				"tickets-to-refund",
-				[]string{
-					event.TicketID,
-					event.CustomerEmail,
-					event.Price.Amount,
-					cmp.Or(event.Price.Currency, "USD"),
-				},
-			)
+			return mrr.appendSheetRows(ctx, event.TicketID, *event)
+		},
+	)
+}
+
+// appendSheetRows requests to append the rows configured for the event in each of its sheets.
+func (mrr *MessageRouterRunner) appendSheetRows(ctx context.Context, ticketID string, event any) error {
+	eventType := cqrs.StructName(event)
+	rows, err := mrr.sheets.Rows(eventType, event)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		err := mrr.commandBus.Send(ctx, adapter.AppendTicketRow{
+			TicketID:  ticketID,
+			SheetName: row.Sheet,
+			Row:       row.Columns,
+			EventType: eventType,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mrr *MessageRouterRunner) refundPaymentHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"refundPaymentHandler",
+		func(ctx context.Context, event *adapter.TicketBookingCanceled) error {
+			refund, err := mrr.clients.Payments.RefundPayment(ctx, adapter.RefundPaymentRequest{
+				PaymentReference: event.TicketID,
+				Reason:           "ticket booking canceled",
+				// One refund per ticket, no matter how many times the cancellation is delivered.
+				DeduplicationID: "refund-" + event.TicketID,
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.eventBus.Publish(ctx, adapter.TicketRefunded{
+				TicketID:        event.TicketID,
+				RefundReference: refund.RefundReference,
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) storeReceiptHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"storeReceiptHandler",
+		func(ctx context.Context, event *adapter.TicketReceiptIssued) error {
+			err := mrr.repositories.Receipts.Add(ctx, adapter.IssuedReceipt{
+				TicketID:      event.TicketID,
+				ReceiptNumber: event.ReceiptNumber,
+				IssuedAt:      event.IssuedAt,
+			})
+			if errors.Is(err, adapter.ErrInvalidTicketID) {
+				// Redelivering the event would never store the receipt.
+				log.FromContext(ctx).WithError(err).Error("Dropping receipt of invalid ticket")
+				return nil
+			}
+
+			return err
 		},
 	)
 }
@@ -1,9 +1,12 @@
 package message
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"tickets/adapter"
 	"tickets/middleware/asyncMiddleware"
+	"tickets/sheets"
 	"time"
 
 	"github.com/ThreeDotsLabs/watermill"
@@ -22,6 +25,27 @@ type MessageRouterRunner struct {
 	g         *errgroup.Group
 	router    *message.Router
 	processor *cqrs.EventProcessor
+	publisher message.Publisher
+	eventBus  *cqrs.EventBus
+
+	repositories adapter.Repositories
+
+	commandProcessor *cqrs.CommandProcessor
+	commandBus       *cqrs.CommandBus
+
+	bookingProcesses adapter.BookingProcessRepository
+	bookingTimeout   time.Duration
+	sentEffects      adapter.DedupeStore
+	spreadsheets     adapter.IdempotentSpreadsheets
+	sheets           sheets.Config
+
+	subscribers SubscriberFactory
+	namespace   string
+	concurrency HandlersConcurrency
+	chaos       *asyncMiddleware.Chaos
+
+	clock adapter.Clock
+	ids   adapter.IDGenerator
 }
 
 type NewMessageRouterRunnerInfo struct {
@@ -30,15 +54,93 @@ type NewMessageRouterRunnerInfo struct {
 	Logger  watermill.LoggerAdapter
 	Clients adapter.Clients
 	G       *errgroup.Group
+
+	Repositories adapter.Repositories
+
+	// Publisher is used by the handlers to publish its outcome events.
+	Publisher message.Publisher
+
+	// BookingTimeout is optional. It is the time a booking process has to finish its steps.
+	BookingTimeout time.Duration
+
+	// Sheets is optional. It defaults to sheets.DefaultConfig.
+	// Only TicketBookingConfirmed and TicketBookingCanceled events append rows.
+	Sheets *sheets.Config
+
+	// Concurrency is optional. By default, each handler process one message at a time.
+	Concurrency HandlersConcurrency
+
+	// Namespace is optional. It prefixes the subscribed topics, the consumer groups and
+	// the keys of the Redis stores, so it must match the prefix of the topics the publisher writes to.
+	Namespace string
+
+	// Subscribers, BookingProcesses and SentEffects are optional. They default to
+	// the Redis based ones, built with RDB.
+	Subscribers      SubscriberFactory
+	BookingProcesses adapter.BookingProcessRepository
+	SentEffects      adapter.DedupeStore
+
+	// Chaos is optional, only for test or staging. It misbehaves the delivery of the messages.
+	Chaos *asyncMiddleware.Chaos
+
+	// Clock and IDs are optional. They default to the system clock and random short UUIDs.
+	Clock adapter.Clock
+	IDs   adapter.IDGenerator
 }
 
 func NewMessageRouterRunner(info NewMessageRouterRunnerInfo) *MessageRouterRunner {
+	sheetsConfig := sheets.DefaultConfig()
+	if info.Sheets != nil {
+		sheetsConfig = *info.Sheets
+	}
+
+	var clock adapter.Clock = adapter.SystemClock{}
+	if info.Clock != nil {
+		clock = info.Clock
+	}
+
+	var ids adapter.IDGenerator = adapter.ShortUUIDGenerator{}
+	if info.IDs != nil {
+		ids = info.IDs
+	}
+
+	var sentEffects adapter.DedupeStore = adapter.NewRedisDedupeStore(info.RDB, clock, info.Namespace)
+	if info.SentEffects != nil {
+		sentEffects = info.SentEffects
+	}
+
+	var bookingProcesses adapter.BookingProcessRepository = adapter.NewBookingProcessRedisRepository(info.RDB, clock, info.Namespace)
+	if info.BookingProcesses != nil {
+		bookingProcesses = info.BookingProcesses
+	}
+
+	subscribers := info.Subscribers
+	if subscribers == nil {
+		subscribers = newRedisSubscriberFactory(info.RDB, info.Logger)
+	}
+
 	return &MessageRouterRunner{
 		ctx:     info.Ctx,
 		rdb:     info.RDB,
 		logger:  info.Logger,
 		clients: info.Clients,
 		g:       info.G,
+
+		publisher:        info.Publisher,
+		repositories:     info.Repositories,
+		bookingProcesses: bookingProcesses,
+		bookingTimeout:   cmp.Or(info.BookingTimeout, defaultBookingTimeout),
+		sentEffects:      sentEffects,
+		spreadsheets:     adapter.NewIdempotentSpreadsheets(info.Clients.Spreadsheets, sentEffects),
+		sheets:           sheetsConfig,
+
+		subscribers: subscribers,
+		namespace:   info.Namespace,
+		concurrency: info.Concurrency,
+		chaos:       info.Chaos,
+
+		clock: clock,
+		ids:   ids,
 	}
 }
 
@@ -58,22 +160,71 @@ func (mrr *MessageRouterRunner) RunAsync() {
 	}.Middleware)
 
 	mrr.router.AddMiddleware(asyncMiddleware.CorrelationID)
+	mrr.router.AddMiddleware(asyncMiddleware.CausationID)
 	mrr.router.AddMiddleware(asyncMiddleware.Logger2Context)
 	mrr.router.AddMiddleware(asyncMiddleware.MessageLogger)
 	mrr.router.AddMiddleware(asyncMiddleware.TypeAssertion)
+	if mrr.chaos != nil {
+		mrr.router.AddMiddleware(mrr.chaos.Middleware)
+	}
+
+	mrr.eventBus, err = adapter.NewEventBus(mrr.publisher, mrr.ids)
+	if err != nil {
+		panic(fmt.Errorf("unable to create event bus: %w", err))
+	}
+
+	mrr.commandBus, err = adapter.NewCommandBus(mrr.publisher, mrr.ids)
+	if err != nil {
+		panic(fmt.Errorf("unable to create command bus: %w", err))
+	}
+
+	ticketLocks := newKeyedMutex()
 
 	mrr.processor = mustNewEventProcessor(
 		mrr.router,
-		mrr.rdb,
+		mrr.subscribers,
 		mrr.logger,
+		mrr.namespace,
+		mrr.concurrency,
+		ticketLocks,
 	)
 
 	mrr.processor.AddHandlers(
 		mrr.issueReceiptHandler(),
 		mrr.printTicketHandler(),
+		mrr.renderTicketHandler(),
 		mrr.refundTicketHandler(),
+		mrr.refundPaymentHandler(),
+		mrr.storeReceiptHandler(),
+		mrr.sendBookingConfirmationHandler(),
+		mrr.sendRefundNotificationHandler(),
+		mrr.bookingProcessStartHandler(),
+		mrr.bookingProcessReceiptIssuedHandler(),
+		mrr.bookingProcessTicketPrintedHandler(),
+		mrr.bookingProcessNotificationSentHandler(),
+		mrr.bookingProcessTimeoutHandler(),
+		mrr.bookingProcessCancelHandler(),
+		mrr.bookingProcessReceiptVoidedHandler(),
+	)
+
+	mrr.commandProcessor = mustNewCommandProcessor(
+		mrr.router,
+		mrr.subscribers,
+		mrr.logger,
+		mrr.namespace,
+		mrr.concurrency,
+		ticketLocks,
 	)
 
+	err = mrr.commandProcessor.AddHandlers(
+		mrr.issueReceiptCommandHandler(),
+		mrr.voidReceiptCommandHandler(),
+		mrr.appendTicketRowCommandHandler(),
+	)
+	if err != nil {
+		panic(err)
+	}
+
 	/* mrr.router.AddNoPublisherHandler(
 		"issueReceiptHandler",
 		port.TicketBookingConfirmedTopic,
@@ -150,7 +301,10 @@ func (mrr *MessageRouterRunner) RunAsync() {
 	) */
 
 	mrr.g.Go(func() error {
-		err := mrr.router.Run(context.Background())
+		err := mrr.router.Run(mrr.ctx)
+		if mrr.chaos != nil {
+			mrr.logger.Info(mrr.chaos.Report().String(), nil)
+		}
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,94 @@
+package message
+
+import (
+	"cmp"
+	"context"
+	"tickets/adapter"
+	"tickets/render"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+)
+
+const (
+	bookingConfirmationNotification = "booking_confirmation"
+	refundNotification              = "refund"
+)
+
+func (mrr *MessageRouterRunner) sendBookingConfirmationHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"sendBookingConfirmationHandler",
+		func(ctx context.Context, event *adapter.TicketBookingConfirmed) error {
+			email, err := render.BookingConfirmationEmail(render.TicketDocument{
+				TicketID:      event.TicketID,
+				CustomerEmail: event.CustomerEmail,
+				PriceAmount:   event.Price.Amount,
+				PriceCurrency: cmp.Or(event.Price.Currency, "USD"),
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.notifyOnce(ctx, event.TicketID, bookingConfirmationNotification, adapter.Notification{
+				To:       event.CustomerEmail,
+				Subject:  email.Subject,
+				HTMLBody: email.HTMLBody,
+			})
+		},
+	)
+}
+
+func (mrr *MessageRouterRunner) sendRefundNotificationHandler() cqrs.EventHandler {
+	return cqrs.NewEventHandler(
+		"sendRefundNotificationHandler",
+		func(ctx context.Context, event *adapter.TicketBookingCanceled) error {
+			email, err := render.RefundEmail(render.TicketDocument{
+				TicketID:      event.TicketID,
+				CustomerEmail: event.CustomerEmail,
+				PriceAmount:   event.Price.Amount,
+				PriceCurrency: cmp.Or(event.Price.Currency, "USD"),
+			})
+			if err != nil {
+				return err
+			}
+
+			return mrr.notifyOnce(ctx, event.TicketID, refundNotification, adapter.Notification{
+				To:       event.CustomerEmail,
+				Subject:  email.Subject,
+				HTMLBody: email.HTMLBody,
+			})
+		},
+	)
+}
+
+// notifyOnce sends the notification unless it was already sent for the ticket.
+// The email is sent before recording it, so a crash in between can still send it twice,
+// but a redelivered message never does.
+func (mrr *MessageRouterRunner) notifyOnce(
+	ctx context.Context,
+	ticketID string,
+	kind string,
+	notification adapter.Notification,
+) error {
+	key := "notification:" + kind + ":" + ticketID
+	sent, err := mrr.sentEffects.Done(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if !sent {
+		err = mrr.clients.Notifier.Notify(ctx, notification)
+		if err != nil {
+			return err
+		}
+
+		err = mrr.sentEffects.MarkDone(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mrr.eventBus.Publish(ctx, adapter.TicketNotificationSent{
+		TicketID:     ticketID,
+		Notification: kind,
+	})
+}
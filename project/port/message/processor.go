@@ -10,20 +10,26 @@ import (
 
 func mustNewEventProcessor(
 	router *message.Router,
-	rdb *redis.Client,
+	subscribers SubscriberFactory,
 	logger watermill.LoggerAdapter,
+	namespace string,
+	concurrency HandlersConcurrency,
+	ticketLocks *keyedMutex,
 ) *cqrs.EventProcessor {
 	ep, err := cqrs.NewEventProcessorWithConfig(
 		router,
 		cqrs.EventProcessorConfig{
 			SubscriberConstructor: func(params cqrs.EventProcessorSubscriberConstructorParams) (message.Subscriber, error) {
-				return redisstream.NewSubscriber(redisstream.SubscriberConfig{
-					Client:        rdb,
-					ConsumerGroup: "event-driven-project." + params.HandlerName,
-				}, logger)
+				return newHandlerSubscriber(subscribers, namespace, params.HandlerName, concurrency)
 			},
 			GenerateSubscribeTopic: func(params cqrs.EventProcessorGenerateSubscribeTopicParams) (string, error) {
-				return params.EventName, nil
+				return namespace + params.EventName, nil
+			},
+			OnHandle: func(params cqrs.EventProcessorOnHandleParams) error {
+				unlock := lockTicketIfSerialized(params.Handler.HandlerName(), params.Message, concurrency, ticketLocks)
+				defer unlock()
+
+				return params.Handler.Handle(params.Message.Context(), params.Event)
 			},
 			Marshaler: cqrs.JSONMarshaler{
 				GenerateName: cqrs.StructName,
@@ -38,3 +44,81 @@ func mustNewEventProcessor(
 
 	return ep
 }
+
+func mustNewCommandProcessor(
+	router *message.Router,
+	subscribers SubscriberFactory,
+	logger watermill.LoggerAdapter,
+	namespace string,
+	concurrency HandlersConcurrency,
+	ticketLocks *keyedMutex,
+) *cqrs.CommandProcessor {
+	cp, err := cqrs.NewCommandProcessorWithConfig(
+		router,
+		cqrs.CommandProcessorConfig{
+			SubscriberConstructor: func(params cqrs.CommandProcessorSubscriberConstructorParams) (message.Subscriber, error) {
+				return newHandlerSubscriber(subscribers, namespace, params.HandlerName, concurrency)
+			},
+			GenerateSubscribeTopic: func(params cqrs.CommandProcessorGenerateSubscribeTopicParams) (string, error) {
+				return namespace + params.CommandName, nil
+			},
+			OnHandle: func(params cqrs.CommandProcessorOnHandleParams) error {
+				unlock := lockTicketIfSerialized(params.Handler.HandlerName(), params.Message, concurrency, ticketLocks)
+				defer unlock()
+
+				return params.Handler.Handle(params.Message.Context(), params.Command)
+			},
+			Marshaler: cqrs.JSONMarshaler{
+				GenerateName: cqrs.StructName,
+			},
+			Logger: logger,
+		},
+	)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return cp
+}
+
+// SubscriberFactory creates a subscriber of the given consumer group. The subscribers
+// of the same group share the messages, while each group gets all of them.
+type SubscriberFactory func(consumerGroup string) (message.Subscriber, error)
+
+func newRedisSubscriberFactory(rdb *redis.Client, logger watermill.LoggerAdapter) SubscriberFactory {
+	return func(consumerGroup string) (message.Subscriber, error) {
+		return redisstream.NewSubscriber(redisstream.SubscriberConfig{
+			Client:        rdb,
+			ConsumerGroup: consumerGroup,
+		}, logger)
+	}
+}
+
+func newHandlerSubscriber(
+	subscribers SubscriberFactory,
+	namespace string,
+	handlerName string,
+	concurrency HandlersConcurrency,
+) (message.Subscriber, error) {
+	return newPooledSubscriber(
+		concurrency.For(handlerName).Consumers,
+		func() (message.Subscriber, error) {
+			return subscribers("event-driven-project." + namespace + handlerName)
+		},
+	)
+}
+
+func lockTicketIfSerialized(
+	handlerName string,
+	msg *message.Message,
+	concurrency HandlersConcurrency,
+	ticketLocks *keyedMutex,
+) (unlock func()) {
+	ticketID := messageTicketID(msg)
+	if !concurrency.For(handlerName).SerializeByTicket || ticketID == "" {
+		return func() {}
+	}
+
+	return ticketLocks.Lock(handlerName + ":" + ticketID)
+}
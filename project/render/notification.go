@@ -0,0 +1,54 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+// Email is a rendered notification for a customer.
+type Email struct {
+	Subject  string
+	HTMLBody string
+}
+
+var bookingConfirmationTemplate = template.Must(template.New("booking-confirmation").Parse(`<!DOCTYPE html>
+<html>
+<body>
+	<p>Hello,</p>
+	<p>Your booking is confirmed. Your ticket <strong>{{.TicketID}}</strong> costs {{.PriceAmount}} {{.PriceCurrency}}.</p>
+	<p>See you at the show!</p>
+</body>
+</html>
+`))
+
+var refundTemplate = template.Must(template.New("refund").Parse(`<!DOCTYPE html>
+<html>
+<body>
+	<p>Hello,</p>
+	<p>Your booking of ticket <strong>{{.TicketID}}</strong> has been canceled.</p>
+	<p>{{.PriceAmount}} {{.PriceCurrency}} will be refunded to your payment method.</p>
+</body>
+</html>
+`))
+
+func BookingConfirmationEmail(ticket TicketDocument) (Email, error) {
+	return renderEmail(bookingConfirmationTemplate, "Your ticket "+ticket.TicketID+" is confirmed", ticket)
+}
+
+func RefundEmail(ticket TicketDocument) (Email, error) {
+	return renderEmail(refundTemplate, "Your ticket "+ticket.TicketID+" has been canceled", ticket)
+}
+
+func renderEmail(tmpl *template.Template, subject string, ticket TicketDocument) (Email, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, ticket)
+	if err != nil {
+		return Email{}, fmt.Errorf("unable to render %s email: %w", tmpl.Name(), err)
+	}
+
+	return Email{
+		Subject:  subject,
+		HTMLBody: buf.String(),
+	}, nil
+}
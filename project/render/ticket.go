@@ -0,0 +1,97 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+const qrCodeSize = 256
+
+// TicketDocument holds the data printed in a ticket.
+type TicketDocument struct {
+	TicketID      string
+	CustomerEmail string
+	PriceAmount   string
+	PriceCurrency string
+}
+
+var ticketHTMLTemplate = template.Must(template.New("ticket").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Ticket {{.TicketID}}</title>
+</head>
+<body>
+	<h1>Ticket</h1>
+	<img src="data:image/png;base64,{{.QRCode}}" alt="{{.TicketID}}" width="{{.QRCodeSize}}" height="{{.QRCodeSize}}">
+	<dl>
+		<dt>Ticket ID</dt><dd>{{.TicketID}}</dd>
+		<dt>Customer</dt><dd>{{.CustomerEmail}}</dd>
+		<dt>Price</dt><dd>{{.PriceAmount}} {{.PriceCurrency}}</dd>
+	</dl>
+</body>
+</html>
+`))
+
+// TicketHTML renders the ticket as an HTML page with a QR code of the ticket ID.
+func TicketHTML(ticket TicketDocument) ([]byte, error) {
+	qrCode, err := qrcode.Encode(ticket.TicketID, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode QR code: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = ticketHTMLTemplate.Execute(&buf, struct {
+		TicketDocument
+		QRCode     string
+		QRCodeSize int
+	}{
+		TicketDocument: ticket,
+		QRCode:         base64.StdEncoding.EncodeToString(qrCode),
+		QRCodeSize:     qrCodeSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to render ticket html: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// TicketPDF renders the ticket as a PDF document with a QR code of the ticket ID.
+func TicketPDF(ticket TicketDocument) ([]byte, error) {
+	qrCode, err := qrcode.Encode(ticket.TicketID, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode QR code: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.Cell(0, 12, "Ticket")
+	pdf.Ln(14)
+
+	pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrCode))
+	pdf.ImageOptions("qr", 10, pdf.GetY(), 50, 50, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.Ln(55)
+
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.Cell(0, 8, "Ticket ID: "+ticket.TicketID)
+	pdf.Ln(8)
+	pdf.Cell(0, 8, "Customer: "+ticket.CustomerEmail)
+	pdf.Ln(8)
+	pdf.Cell(0, 8, "Price: "+ticket.PriceAmount+" "+ticket.PriceCurrency)
+
+	var buf bytes.Buffer
+	err = pdf.Output(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render ticket pdf: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package render_test
+
+import (
+	"encoding/base64"
+	"html"
+	"regexp"
+	"testing"
+	"tickets/render"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var document = render.TicketDocument{
+	TicketID:      "b2c1a7d4-1d0e-4c39-8b6f-7d64f2a4f6e1",
+	CustomerEmail: "truman@capote.com",
+	PriceAmount:   "50.00",
+	PriceCurrency: "USD",
+}
+
+func TestTicketHTML(t *testing.T) {
+	page, err := render.TicketHTML(document)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(page), document.TicketID)
+
+	// The encoding is deterministic, so the QR code holds the ticket ID when it matches its encoding.
+	matches := regexp.MustCompile(`data:image/png;base64,([^"]+)`).FindSubmatch(page)
+	require.Len(t, matches, 2, "QR code not found")
+	qrCode, err := base64.StdEncoding.DecodeString(html.UnescapeString(string(matches[1])))
+	require.NoError(t, err)
+
+	expected, err := qrcode.Encode(document.TicketID, qrcode.Medium, 256)
+	require.NoError(t, err)
+	assert.Equal(t, expected, qrCode)
+}
+
+func TestTicketPDF(t *testing.T) {
+	pdf, err := render.TicketPDF(document)
+	require.NoError(t, err)
+
+	assert.True(t, len(pdf) > 0)
+	assert.Equal(t, "%PDF", string(pdf[:4]))
+}
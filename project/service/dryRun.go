@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"tickets/adapter"
+	"tickets/port/message"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/redis/go-redis/v9"
+)
+
+// dryRunConsumerGroupPrefix keeps the dry-run handlers in their own consumer groups,
+// so they get a copy of the traffic and the production handlers still get all of it.
+const dryRunConsumerGroupPrefix = "dry-run."
+
+// dryRun swaps the side effects of the service for no-op implementations. They only count
+// the calls, so a dry run can run for as long as the production handlers.
+type dryRun struct {
+	clock adapter.Clock
+	ids   adapter.IDGenerator
+
+	mu     sync.Mutex
+	counts DryRunReport
+}
+
+func newDryRun(clock adapter.Clock, ids adapter.IDGenerator) *dryRun {
+	return &dryRun{
+		clock: clock,
+		ids:   ids,
+		counts: DryRunReport{
+			Published: map[string]int{},
+			Scheduled: map[string]int{},
+			Rows:      map[string]int{},
+		},
+	}
+}
+
+func (d *dryRun) count(update func(counts *DryRunReport)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	update(&d.counts)
+}
+
+func (d *dryRun) clients() adapter.Clients {
+	return adapter.Clients{
+		Receipts:     dryRunReceipts{d},
+		Spreadsheets: dryRunSpreadsheets{d},
+		Payments:     dryRunPayments{d},
+		Files:        dryRunFiles{d},
+		Notifier:     dryRunNotifier{d},
+	}
+}
+
+func (d *dryRun) repositories() adapter.Repositories {
+	return adapter.Repositories{
+		Receipts: dryRunReceiptsRepository{d},
+	}
+}
+
+// infrastructure keeps only the subscribers of the given infrastructure, or the Redis ones
+// when it has none. The messages are counted instead of published, and the booking processes
+// and sent effects are kept in memory.
+func (d *dryRun) infrastructure(
+	base *Infrastructure,
+	redisClient *redis.Client,
+	logger watermill.LoggerAdapter,
+	clock adapter.Clock,
+) *Infrastructure {
+	var subscribers message.SubscriberFactory
+	if base != nil && base.Subscribers != nil {
+		subscribers = base.Subscribers
+	} else {
+		subscribers = newDryRunRedisSubscribers(redisClient, logger)
+	}
+
+	bookingProcesses := adapter.NewBookingProcessRepositoryMock()
+	bookingProcesses.Clock = clock
+
+	return &Infrastructure{
+		Publisher: dryRunPublisher{d},
+		Subscribers: func(consumerGroup string) (watermillMessage.Subscriber, error) {
+			return subscribers(dryRunConsumerGroupPrefix + consumerGroup)
+		},
+		Scheduler:        dryRunScheduler{d},
+		BookingProcesses: bookingProcesses,
+		SentEffects:      adapter.NewDedupeStoreMock(),
+	}
+}
+
+// newDryRunRedisSubscribers starts the new consumer groups from the latest message,
+// so a dry run only handles the traffic that comes after it starts.
+func newDryRunRedisSubscribers(redisClient *redis.Client, logger watermill.LoggerAdapter) message.SubscriberFactory {
+	return func(consumerGroup string) (watermillMessage.Subscriber, error) {
+		return redisstream.NewSubscriber(redisstream.SubscriberConfig{
+			Client:        redisClient,
+			ConsumerGroup: consumerGroup,
+			OldestId:      "$",
+		}, logger)
+	}
+}
+
+// DryRunReport tells what the handlers would have done out of dry-run mode.
+type DryRunReport struct {
+	// Published and Scheduled count the captured messages by topic.
+	Published map[string]int
+	Scheduled map[string]int
+
+	ReceiptsIssued int
+	ReceiptsVoided int
+	ReceiptsStored int
+	// Rows counts the appended rows by sheet.
+	Rows          map[string]int
+	Refunds       int
+	Notifications int
+	Files         int
+}
+
+func (d *dryRun) report() DryRunReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := d.counts
+	report.Published = maps.Clone(d.counts.Published)
+	report.Scheduled = maps.Clone(d.counts.Scheduled)
+	report.Rows = maps.Clone(d.counts.Rows)
+
+	return report
+}
+
+func (r DryRunReport) String() string {
+	var b strings.Builder
+	b.WriteString("dry run report:")
+	writeCounts(&b, "published", r.Published)
+	writeCounts(&b, "scheduled", r.Scheduled)
+	writeCounts(&b, "rows appended", r.Rows)
+	fmt.Fprintf(&b, "\n  receipts: %d issued, %d voided, %d stored", r.ReceiptsIssued, r.ReceiptsVoided, r.ReceiptsStored)
+	fmt.Fprintf(&b, "\n  refunds: %d", r.Refunds)
+	fmt.Fprintf(&b, "\n  notifications: %d", r.Notifications)
+	fmt.Fprintf(&b, "\n  files: %d", r.Files)
+
+	return b.String()
+}
+
+func writeCounts(b *strings.Builder, title string, counts map[string]int) {
+	fmt.Fprintf(b, "\n  %s:", title)
+	if len(counts) == 0 {
+		b.WriteString(" none")
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(b, "\n    %s: %d", key, counts[key])
+	}
+}
+
+type dryRunPublisher struct{ d *dryRun }
+
+func (p dryRunPublisher) Publish(topic string, messages ...*watermillMessage.Message) error {
+	p.d.count(func(counts *DryRunReport) {
+		counts.Published[topic] += len(messages)
+	})
+	return nil
+}
+
+func (p dryRunPublisher) Close() error {
+	return nil
+}
+
+type dryRunScheduler struct{ d *dryRun }
+
+func (s dryRunScheduler) Schedule(topic string, msg *watermillMessage.Message, deliverAt time.Time) error {
+	s.d.count(func(counts *DryRunReport) {
+		counts.Scheduled[topic]++
+	})
+	return nil
+}
+
+func (s dryRunScheduler) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+type dryRunReceipts struct{ d *dryRun }
+
+func (r dryRunReceipts) IssueReceipt(ctx context.Context, request adapter.IssueReceiptRequest) (adapter.IssueReceiptResponse, error) {
+	r.d.count(func(counts *DryRunReport) {
+		counts.ReceiptsIssued++
+	})
+	return adapter.IssueReceiptResponse{
+		ReceiptNumber: r.d.ids.NewID(ctx),
+		IssuedAt:      r.d.clock.Now(),
+	}, nil
+}
+
+func (r dryRunReceipts) VoidReceipt(ctx context.Context, request adapter.VoidReceiptRequest) error {
+	r.d.count(func(counts *DryRunReport) {
+		counts.ReceiptsVoided++
+	})
+	return nil
+}
+
+type dryRunSpreadsheets struct{ d *dryRun }
+
+func (s dryRunSpreadsheets) AppendRow(ctx context.Context, sheetName string, row []string) error {
+	s.d.count(func(counts *DryRunReport) {
+		counts.Rows[sheetName]++
+	})
+	return nil
+}
+
+type dryRunPayments struct{ d *dryRun }
+
+func (p dryRunPayments) RefundPayment(ctx context.Context, request adapter.RefundPaymentRequest) (adapter.RefundPaymentResponse, error) {
+	p.d.count(func(counts *DryRunReport) {
+		counts.Refunds++
+	})
+	return adapter.RefundPaymentResponse{
+		RefundReference: request.DeduplicationID,
+	}, nil
+}
+
+// dryRunFiles drops the content of the files, so they are never found.
+type dryRunFiles struct{ d *dryRun }
+
+func (f dryRunFiles) Save(ctx context.Context, name string, content []byte) (string, error) {
+	f.d.count(func(counts *DryRunReport) {
+		counts.Files++
+	})
+	return name, nil
+}
+
+func (f dryRunFiles) Load(ctx context.Context, name string) ([]byte, error) {
+	return nil, adapter.ErrFileNotFound
+}
+
+type dryRunNotifier struct{ d *dryRun }
+
+func (n dryRunNotifier) Notify(ctx context.Context, notification adapter.Notification) error {
+	n.d.count(func(counts *DryRunReport) {
+		counts.Notifications++
+	})
+	return nil
+}
+
+// dryRunReceiptsRepository drops the receipts, so they are never found.
+type dryRunReceiptsRepository struct{ d *dryRun }
+
+func (r dryRunReceiptsRepository) Add(ctx context.Context, receipt adapter.IssuedReceipt) error {
+	r.d.count(func(counts *DryRunReport) {
+		counts.ReceiptsStored++
+	})
+	return nil
+}
+
+func (r dryRunReceiptsRepository) FindByTicketID(ctx context.Context, ticketID string) (adapter.IssuedReceipt, error) {
+	return adapter.IssuedReceipt{}, adapter.ErrReceiptNotFound
+}
@@ -1,25 +1,91 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"tickets/adapter"
+	"tickets/decorator"
+	"tickets/middleware/asyncMiddleware"
 	"tickets/port/http"
 	"tickets/port/message"
+	"tickets/sheets"
+	"time"
 
 	"github.com/ThreeDotsLabs/go-event-driven/common/log"
 	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/jmoiron/sqlx"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	defaultCassettePath = "data/cassette.jsonl"
+)
+
+// Options holds the optional settings of the service.
+type Options struct {
+	HandlersConcurrency message.HandlersConcurrency
+	BookingTimeout      time.Duration
+	Sheets              *sheets.Config
+
+	// HTTPAddr is the address of the HTTP API. It defaults to ":8080".
+	HTTPAddr string
+	// Namespace isolates the streams and consumer groups of the service,
+	// so several instances can share the same Redis without interfering.
+	Namespace string
+
+	// Chaos misbehaves the delivery of the messages. Only for test or staging.
+	Chaos *asyncMiddleware.Chaos
+
+	// Clock and IDs default to the system clock and random short UUIDs. Tests and replays
+	// set them to make the times, receipt numbers and message UUIDs deterministic.
+	Clock adapter.Clock
+	IDs   adapter.IDGenerator
+
+	// Infrastructure replaces the Redis based Pub/Sub, stores and scheduler,
+	// e.g. to run the service in memory. When set, the Redis client is not used.
+	Infrastructure *Infrastructure
+
+	// Cassette records the calls to the receipts and spreadsheets APIs, or replays them.
+	// The service closes it once stopped.
+	Cassette *adapter.Cassette
+
+	// DryRun runs the handlers against the real traffic, in their own consumer groups,
+	// without side effects: the clients and repositories are swapped for counting no-op ones,
+	// and the messages are captured instead of published. See Service.DryRunReport.
+	DryRun bool
+}
+
+type Infrastructure struct {
+	Publisher        watermillMessage.Publisher
+	Subscribers      message.SubscriberFactory
+	Scheduler        Scheduler
+	BookingProcesses adapter.BookingProcessRepository
+	SentEffects      adapter.DedupeStore
+}
+
+// Scheduler holds the delayed messages until they are due.
+type Scheduler interface {
+	decorator.MessageScheduler
+	Run(ctx context.Context) error
+}
+
 type Service struct {
 	redisClient   *redis.Client
 	services      adapter.Clients
+	publisher     watermillMessage.Publisher
+	scheduler     Scheduler
 	messageRunner *message.MessageRouterRunner
 	httpRunner    *http.HTTPRouterRunner
+	dryRun        *dryRun
+	cassette      *adapter.Cassette
 	ctx           context.Context
 	cancel        context.CancelFunc
 	logger        *logrus.Entry
@@ -32,9 +98,31 @@ func New(
 	redisClient *redis.Client,
 	logger *logrus.Entry,
 	clients adapter.Clients,
+	repositories adapter.Repositories,
+	options Options,
 ) Service {
 	serviceContext, cancel := signal.NotifyContext(ctx, os.Interrupt)
 	g, serviceContext := errgroup.WithContext(serviceContext)
+
+	if options.Clock == nil {
+		options.Clock = adapter.SystemClock{}
+	}
+	if options.IDs == nil {
+		options.IDs = adapter.ShortUUIDGenerator{}
+	}
+
+	var dryRun *dryRun
+	if options.DryRun {
+		dryRun = newDryRun(options.Clock, options.IDs)
+		clients = dryRun.clients()
+		repositories = dryRun.repositories()
+	}
+
+	if options.Cassette != nil {
+		clients.Receipts = adapter.NewCassetteReceiptsService(clients.Receipts, options.Cassette)
+		clients.Spreadsheets = adapter.NewCassetteSpreadsheetsAPI(clients.Spreadsheets, options.Cassette)
+	}
+
 	service := Service{
 		redisClient: redisClient,
 		ctx:         serviceContext,
@@ -43,7 +131,30 @@ func New(
 		logger:      logger,
 		wlogger:     log.NewWatermill(logrus.NewEntry(logrus.StandardLogger())),
 		errgrp:      g,
+		dryRun:      dryRun,
+		cassette:    options.Cassette,
+	}
+
+	// The namespace prefixes the topics, and the keys of the scheduler and stores.
+	topicPrefix := namespacePrefix(options.Namespace)
+
+	infrastructure := options.Infrastructure
+	if dryRun != nil {
+		infrastructure = dryRun.infrastructure(infrastructure, redisClient, service.wlogger, options.Clock)
+	} else if infrastructure == nil {
+		infrastructure = redisInfrastructure(redisClient, service.wlogger, options.Clock, topicPrefix)
 	}
+	publisher := infrastructure.Publisher
+
+	service.scheduler = infrastructure.Scheduler
+	service.publisher = decorator.DecorateWithCorrelationPublisherDecorator(
+		decorator.DecorateWithCausationPublisherDecorator(
+			decorator.DecorateWithTopicPrefixPublisherDecorator(
+				decorator.DecorateWithDelayedPublisherDecorator(publisher, service.scheduler, options.Clock),
+				topicPrefix,
+			),
+		),
+	)
 
 	service.messageRunner = message.NewMessageRouterRunner(message.NewMessageRouterRunnerInfo{
 		Ctx:     serviceContext,
@@ -51,18 +162,65 @@ func New(
 		Logger:  service.wlogger,
 		Clients: service.services,
 		G:       service.errgrp,
+
+		Repositories:   repositories,
+		Publisher:      service.publisher,
+		BookingTimeout: options.BookingTimeout,
+		Sheets:         options.Sheets,
+		Concurrency:    options.HandlersConcurrency,
+		Namespace:      topicPrefix,
+
+		Subscribers:      infrastructure.Subscribers,
+		BookingProcesses: infrastructure.BookingProcesses,
+		SentEffects:      infrastructure.SentEffects,
+		Chaos:            options.Chaos,
+
+		Clock: options.Clock,
+		IDs:   options.IDs,
 	})
 
 	service.httpRunner = http.NewHTTPRouterRunner(http.NewHTTPRouterRunnerInfo{
-		Ctx:    serviceContext,
-		RDB:    service.redisClient,
-		Logger: service.wlogger,
-		G:      service.errgrp,
+		Ctx:          serviceContext,
+		Publisher:    service.publisher,
+		Repositories: repositories,
+		Logger:       service.wlogger,
+		G:            service.errgrp,
+		Addr:         options.HTTPAddr,
+		IDs:          options.IDs,
 	})
 
 	return service
 }
 
+// redisInfrastructure leaves the subscribers and stores unset, so the message router
+// builds the Redis based ones.
+func redisInfrastructure(
+	redisClient *redis.Client,
+	logger watermill.LoggerAdapter,
+	clock adapter.Clock,
+	keyPrefix string,
+) *Infrastructure {
+	publisher, err := redisstream.NewPublisher(redisstream.PublisherConfig{
+		Client: redisClient,
+	}, logger)
+	if err != nil {
+		panic(fmt.Errorf("unable to create publisher: %w", err))
+	}
+
+	return &Infrastructure{
+		Publisher: publisher,
+		Scheduler: adapter.NewMessageScheduler(redisClient, publisher, logger, clock, keyPrefix),
+	}
+}
+
+func namespacePrefix(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+
+	return namespace + "."
+}
+
 func commonTools() (
 	logger *logrus.Entry,
 	rdb *redis.Client,
@@ -81,28 +239,184 @@ func commonTools() (
 func DefaultFromEnv() Service {
 	// TODO: Use wire to initialize all this: https://github.com/google/wire/blob/main/_tutorial/README.md
 	logger, rdb, ctx := commonTools()
-	services := adapter.NewClients(os.Getenv("GATEWAY_ADDR"))
+	services := adapter.NewClientsWithConfig(clientsConfigFromEnv())
+
+	db, err := sqlx.Open("postgres", os.Getenv("POSTGRES_URL"))
+	if err != nil {
+		panic(fmt.Errorf("unable to open database: %w", err))
+	}
+
+	concurrency, err := message.ParseHandlersConcurrency(os.Getenv("HANDLERS_CONCURRENCY"))
+	if err != nil {
+		panic(fmt.Errorf("invalid HANDLERS_CONCURRENCY: %w", err))
+	}
 
 	return New(
 		ctx,
 		rdb,
 		logger,
 		services,
+		adapter.NewRepositories(db),
+		Options{
+			HandlersConcurrency: concurrency,
+			BookingTimeout:      durationFromEnv("BOOKING_TIMEOUT"),
+			Sheets:              sheetsConfigFromEnv(),
+			HTTPAddr:            os.Getenv("HTTP_ADDR"),
+			Namespace:           os.Getenv("NAMESPACE"),
+			Chaos:               chaosFromEnv(),
+			Cassette:            cassetteFromEnv(),
+			DryRun:              boolFromEnv("DRY_RUN"),
+		},
 	)
 }
 
+func clientsConfigFromEnv() adapter.ClientsConfig {
+	config := adapter.DefaultClientsConfig(os.Getenv("GATEWAY_ADDR"))
+	config.ReceiptsAddr = os.Getenv("RECEIPTS_ADDR")
+	config.SpreadsheetsAddr = os.Getenv("SPREADSHEETS_ADDR")
+	config.PaymentsAddr = os.Getenv("PAYMENTS_ADDR")
+	config.Receipts = httpClientConfigFromEnv("RECEIPTS", config.Receipts)
+	config.Spreadsheets = httpClientConfigFromEnv("SPREADSHEETS", config.Spreadsheets)
+	config.Payments = httpClientConfigFromEnv("PAYMENTS", config.Payments)
+	config.FileStorageDir = cmp.Or(os.Getenv("FILE_STORAGE_DIR"), config.FileStorageDir)
+	config.SMTP.Addr = cmp.Or(os.Getenv("SMTP_ADDR"), config.SMTP.Addr)
+	config.SMTP.From = cmp.Or(os.Getenv("SMTP_FROM"), config.SMTP.From)
+	config.SMTP.Username = os.Getenv("SMTP_USERNAME")
+	config.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	config.SMTP.Timeout = cmp.Or(durationFromEnv("SMTP_TIMEOUT"), config.SMTP.Timeout)
+
+	return config
+}
+
+// httpClientConfigFromEnv overrides the config with the <SERVICE>_HTTP_* variables.
+func httpClientConfigFromEnv(service string, config adapter.HTTPClientConfig) adapter.HTTPClientConfig {
+	config.Timeout = cmp.Or(durationFromEnv(service+"_HTTP_TIMEOUT"), config.Timeout)
+	config.MaxConnsPerHost = intFromEnv(service+"_HTTP_MAX_CONNS", config.MaxConnsPerHost)
+	config.MaxIdleConnsPerHost = intFromEnv(service+"_HTTP_MAX_IDLE_CONNS", config.MaxIdleConnsPerHost)
+	config.Retries = intFromEnv(service+"_HTTP_RETRIES", config.Retries)
+
+	return config
+}
+
+// cassetteFromEnv records the calls to the receipts and spreadsheets APIs in CASSETTE_PATH,
+// or replays them from it, when CASSETTE_MODE is "record" or "replay".
+func cassetteFromEnv() *adapter.Cassette {
+	mode := os.Getenv("CASSETTE_MODE")
+	if mode == "" {
+		return nil
+	}
+
+	cassette, err := adapter.OpenCassette(
+		cmp.Or(os.Getenv("CASSETTE_PATH"), defaultCassettePath),
+		adapter.CassetteMode(mode),
+		adapter.SystemClock{},
+	)
+	if err != nil {
+		panic(fmt.Errorf("invalid CASSETTE_MODE or CASSETTE_PATH: %w", err))
+	}
+
+	return cassette
+}
+
+// chaosFromEnv enables the chaos middleware when any CHAOS_*_PROBABILITY is set.
+func chaosFromEnv() *asyncMiddleware.Chaos {
+	config := asyncMiddleware.ChaosConfig{
+		ReplayProbability:  floatFromEnv("CHAOS_REPLAY_PROBABILITY"),
+		DelayProbability:   floatFromEnv("CHAOS_DELAY_PROBABILITY"),
+		MaxDelay:           durationFromEnv("CHAOS_MAX_DELAY"),
+		ReorderProbability: floatFromEnv("CHAOS_REORDER_PROBABILITY"),
+		ReorderWindow:      durationFromEnv("CHAOS_REORDER_WINDOW"),
+		Seed:               uint64(intFromEnv("CHAOS_SEED", 0)),
+	}
+	if !config.Enabled() {
+		return nil
+	}
+
+	return asyncMiddleware.NewChaos(config)
+}
+
+func floatFromEnv(key string) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s: %w", key, err))
+	}
+
+	return f
+}
+
+func boolFromEnv(key string) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s: %w", key, err))
+	}
+
+	return b
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s: %w", key, err))
+	}
+
+	return n
+}
+
+func sheetsConfigFromEnv() *sheets.Config {
+	path := os.Getenv("SHEETS_CONFIG")
+	if path == "" {
+		return nil
+	}
+
+	config, err := sheets.LoadConfig(path)
+	if err != nil {
+		panic(fmt.Errorf("invalid SHEETS_CONFIG: %w", err))
+	}
+
+	return &config
+}
+
+func durationFromEnv(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s: %w", key, err))
+	}
+
+	return d
+}
+
 func DefaultMock() (Service, adapter.ClientMocks) {
 	logger, rdb, ctx := commonTools()
 	services := adapter.NewClientsMock()
+	repositories := adapter.NewRepositoriesMock()
 
 	return New(
 		ctx,
 		rdb,
 		logger,
-		adapter.Clients{
-			Receipts:     services.Receipts,
-			Spreadsheets: services.Spreadsheets,
-		},
+		services.Clients(),
+		repositories.Repositories(),
+		Options{},
 	), services
 }
 
@@ -114,5 +428,39 @@ func (s Service) Run() error {
 
 	s.httpRunner.RunAsync()
 
-	return s.errgrp.Wait()
+	s.errgrp.Go(func() error {
+		return s.scheduler.Run(s.ctx)
+	})
+
+	err := s.errgrp.Wait()
+	if s.dryRun != nil {
+		s.logger.Info(s.dryRun.report().String())
+	}
+	if s.cassette != nil {
+		if closeErr := s.cassette.Close(); closeErr != nil {
+			s.logger.WithError(closeErr).Error("Unable to close the cassette")
+		}
+	}
+
+	return err
+}
+
+// DryRunReport tells what the handlers would have done so far. It is false when the service
+// is not in dry-run mode.
+func (s Service) DryRunReport() (DryRunReport, bool) {
+	if s.dryRun == nil {
+		return DryRunReport{}, false
+	}
+
+	return s.dryRun.report(), true
+}
+
+// Running is closed once the service consumes messages and serves HTTP requests.
+func (s Service) Running() <-chan struct{} {
+	return s.httpRunner.Listening()
+}
+
+// HTTPAddr returns the address of the HTTP API. It is only set once Running is closed.
+func (s Service) HTTPAddr() string {
+	return s.httpRunner.Addr().String()
 }
@@ -0,0 +1,193 @@
+package sheets
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed default.json
+var defaultConfig []byte
+
+// Config maps event names to the sheets where a row is appended for each event.
+type Config struct {
+	Events map[string][]SheetTemplate `json:"events"`
+}
+
+// SheetTemplate defines the sheet name and how each column of the row is built.
+type SheetTemplate struct {
+	Sheet   string   `json:"sheet"`
+	Columns []Column `json:"columns"`
+}
+
+// Column takes its value from an event field, like "price.amount", or from a
+// text/template executed with the event payload, like "{{.price.amount}} {{.price.currency}}".
+// Default is used when the value is empty, or when the template references a field
+// the event doesn't have.
+type Column struct {
+	Field    string `json:"field,omitempty"`
+	Template string `json:"template,omitempty"`
+	Default  string `json:"default,omitempty"`
+
+	tmpl *template.Template
+}
+
+// DefaultConfig returns the embedded config.
+func DefaultConfig() Config {
+	config, err := ParseConfig(defaultConfig)
+	if err != nil {
+		panic(fmt.Errorf("invalid default sheets config: %w", err))
+	}
+
+	return config
+}
+
+// LoadConfig reads the config from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to read sheets config: %w", err)
+	}
+
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	err := json.Unmarshal(data, &config)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to parse sheets config: %w", err)
+	}
+
+	for event, templates := range config.Events {
+		for i, sheet := range templates {
+			if sheet.Sheet == "" {
+				return Config{}, fmt.Errorf("missing sheet name for event %s", event)
+			}
+
+			for j, column := range sheet.Columns {
+				if (column.Field == "") == (column.Template == "") {
+					return Config{}, fmt.Errorf("column %d of sheet %s must have either a field or a template", j, sheet.Sheet)
+				}
+
+				if column.Template != "" {
+					tmpl, err := template.New(sheet.Sheet).Option("missingkey=error").Parse(column.Template)
+					if err != nil {
+						return Config{}, fmt.Errorf("invalid template in column %d of sheet %s: %w", j, sheet.Sheet, err)
+					}
+					config.Events[event][i].Columns[j].tmpl = tmpl
+				}
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// Row is a row to append to a sheet.
+type Row struct {
+	Sheet   string
+	Columns []string
+}
+
+// Rows builds the rows to append for the event, or none if the event is not configured.
+func (c Config) Rows(eventName string, event any) ([]Row, error) {
+	templates := c.Events[eventName]
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	payload, err := eventPayload(event)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, 0, len(templates))
+	for _, sheet := range templates {
+		row := Row{
+			Sheet:   sheet.Sheet,
+			Columns: make([]string, 0, len(sheet.Columns)),
+		}
+
+		for _, column := range sheet.Columns {
+			value, err := column.value(payload)
+			if err != nil {
+				return nil, fmt.Errorf("unable to build row for sheet %s: %w", sheet.Sheet, err)
+			}
+			row.Columns = append(row.Columns, value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func (c Column) value(payload map[string]any) (string, error) {
+	var value string
+	if c.tmpl != nil {
+		var buf bytes.Buffer
+		err := c.tmpl.Execute(&buf, payload)
+		var execErr template.ExecError
+		if errors.As(err, &execErr) {
+			// A missing field fails the execution, instead of rendering "<no value>".
+			return c.Default, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		value = buf.String()
+	} else {
+		value = fieldValue(payload, c.Field)
+	}
+
+	if value == "" {
+		return c.Default, nil
+	}
+
+	return value, nil
+}
+
+func eventPayload(event any) (map[string]any, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal event: %w", err)
+	}
+
+	var payload map[string]any
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal event: %w", err)
+	}
+
+	return payload, nil
+}
+
+// fieldValue looks up a dot separated path in the payload.
+func fieldValue(payload map[string]any, path string) string {
+	var value any = payload
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,68 @@
+package sheets_test
+
+import (
+	"testing"
+	"tickets/adapter"
+	"tickets/sheets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfig_Rows(t *testing.T) {
+	rows, err := sheets.DefaultConfig().Rows("TicketBookingConfirmed", adapter.TicketBookingConfirmed{
+		TicketID:      "ticket-1",
+		CustomerEmail: "truman@capote.com",
+		Price: adapter.MoneyPayload{
+			Amount: "50.00",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []sheets.Row{
+		{
+			Sheet:   "tickets-to-print",
+			Columns: []string{"ticket-1", "truman@capote.com", "50.00", "USD"},
+		},
+	}, rows)
+}
+
+func TestConfig_Rows_template(t *testing.T) {
+	config, err := sheets.ParseConfig([]byte(`{
+		"events": {
+			"TicketBookingCanceled": [
+				{
+					"sheet": "refunds-by-price",
+					"columns": [
+						{ "field": "ticket_id" },
+						{ "template": "{{.price.amount}} {{.price.currency}}" },
+						{ "field": "booking_id", "default": "unknown" },
+						{ "template": "{{.venue}}", "default": "no venue" },
+						{ "template": "{{.seat.row}}-{{.seat.number}}", "default": "no seat" }
+					]
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	rows, err := config.Rows("TicketBookingCanceled", adapter.TicketBookingCanceled{
+		TicketID: "ticket-1",
+		Price: adapter.MoneyPayload{
+			Amount:   "50.00",
+			Currency: "EUR",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []sheets.Row{
+		{
+			Sheet:   "refunds-by-price",
+			Columns: []string{"ticket-1", "50.00 EUR", "unknown", "no venue", "no seat"},
+		},
+	}, rows)
+
+	rows, err = config.Rows("TicketBookingConfirmed", adapter.TicketBookingConfirmed{})
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
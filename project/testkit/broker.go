@@ -0,0 +1,140 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+)
+
+// Broker is an in-memory Pub/Sub that knows when all the published messages are handled,
+// so tests can wait for it instead of polling.
+//
+// Every subscription gets all the messages of its topic, so each consumer group
+// must subscribe only once, i.e. handlers must run a single consumer.
+type Broker struct {
+	pubSub *gochannel.GoChannel
+
+	mu            sync.Mutex
+	subscriptions map[string]int
+	pending       int
+	published     []*message.Message
+	updates       chan struct{}
+}
+
+func NewBroker(logger watermill.LoggerAdapter) *Broker {
+	return &Broker{
+		pubSub:        gochannel.NewGoChannel(gochannel.Config{}, logger),
+		subscriptions: map[string]int{},
+		updates:       make(chan struct{}),
+	}
+}
+
+func (b *Broker) Publish(topic string, messages ...*message.Message) error {
+	b.mu.Lock()
+	for _, msg := range messages {
+		published := msg.Copy()
+		published.Metadata.Set(topicMetadataKey, topic)
+		b.published = append(b.published, published)
+	}
+	pending := len(messages) * b.subscriptions[topic]
+	b.pending += pending
+	b.notify()
+	b.mu.Unlock()
+
+	err := b.pubSub.Publish(topic, messages...)
+	if err != nil {
+		// The messages are still in Published, but nobody is going to handle them.
+		b.mu.Lock()
+		b.pending -= pending
+		b.notify()
+		b.mu.Unlock()
+	}
+
+	return err
+}
+
+func (b *Broker) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	messages, err := b.pubSub.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.subscriptions[topic]++
+	b.mu.Unlock()
+
+	out := make(chan *message.Message)
+	go func() {
+		defer close(out)
+
+		for msg := range messages {
+			// A nacked message is sent again as a copy, so it is pending until one copy is acked.
+			go b.watchAck(ctx, msg)
+			out <- msg
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *Broker) watchAck(ctx context.Context, msg *message.Message) {
+	select {
+	case <-msg.Acked():
+		b.mu.Lock()
+		b.pending--
+		b.notify()
+		b.mu.Unlock()
+	case <-msg.Nacked():
+	case <-ctx.Done():
+	}
+}
+
+// Close is a no-op, so the handlers closing their subscribers don't close the broker
+// for the rest. The broker is closed with Shutdown.
+func (b *Broker) Close() error {
+	return nil
+}
+
+func (b *Broker) Shutdown() error {
+	return b.pubSub.Close()
+}
+
+// WaitIdle blocks until every published message has been handled by all its subscribers.
+func (b *Broker) WaitIdle(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		pending := b.pending
+		updates := b.updates
+		b.mu.Unlock()
+
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d messages still pending: %w", pending, ctx.Err())
+		case <-updates:
+		}
+	}
+}
+
+// Published returns a copy of every message published so far, in order.
+// The topic of each message is in its topic metadata.
+func (b *Broker) Published() []*message.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return slices.Clone(b.published)
+}
+
+// notify must be called holding mu.
+func (b *Broker) notify() {
+	close(b.updates)
+	b.updates = make(chan struct{})
+}
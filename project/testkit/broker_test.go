@@ -0,0 +1,27 @@
+package testkit_test
+
+import (
+	"context"
+	"testing"
+	"tickets/testkit"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_failedPublishIsNotPending(t *testing.T) {
+	broker := testkit.NewBroker(watermill.NopLogger{})
+
+	_, err := broker.Subscribe(context.Background(), "topic")
+	require.NoError(t, err)
+	require.NoError(t, broker.Shutdown())
+
+	err = broker.Publish("topic", message.NewMessage("1", nil))
+	require.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, broker.WaitIdle(ctx))
+}
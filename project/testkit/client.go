@@ -0,0 +1,109 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"tickets/adapter"
+	"tickets/domain/ticket"
+	ticketsHTTP "tickets/port/http"
+
+	"github.com/lithammer/shortuuid"
+)
+
+// Client is a typed client of the tickets API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+	}
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *Client) Health(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/health", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return adapter.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// SendTicketsStatus reports the status of the tickets, as the booking platform does.
+func (c *Client) SendTicketsStatus(ctx context.Context, tickets ...ticket.Ticket) error {
+	resp, err := c.do(ctx, http.MethodPost, "/tickets-status", ticketsHTTP.TicketsStatusRequest{
+		Tickets: tickets,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return adapter.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// GetReceipt returns the stored receipt of the ticket, or adapter.ErrReceiptNotFound.
+func (c *Client) GetReceipt(ctx context.Context, ticketID string) (adapter.IssuedReceipt, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/tickets/"+ticketID+"/receipt", nil)
+	if err != nil {
+		return adapter.IssuedReceipt{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return adapter.IssuedReceipt{}, adapter.ErrReceiptNotFound
+	default:
+		return adapter.IssuedReceipt{}, adapter.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var receipt adapter.IssuedReceipt
+	err = json.NewDecoder(resp.Body).Decode(&receipt)
+	if err != nil {
+		return adapter.IssuedReceipt{}, fmt.Errorf("unable to decode receipt of ticket %s: %w", ticketID, err)
+	}
+
+	return receipt, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	var payload bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&payload).Encode(body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode request body: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Correlation-ID", "testkit_"+shortuuid.New())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.httpClient.Do(req)
+}
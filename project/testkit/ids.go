@@ -0,0 +1,32 @@
+package testkit
+
+import (
+	"context"
+	"tickets/adapter"
+	"tickets/middleware/asyncMiddleware"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+)
+
+// NewIDGeneratorMock generates sequential IDs, like "prefix-1", "prefix-2".
+//
+// While handling a message, the IDs are numbered by the message UUID and the handler
+// instead, like "prefix-1/printTicketHandler-1", so they don't depend on the order
+// the concurrent handlers run in.
+func NewIDGeneratorMock(prefix string) *adapter.IDGeneratorMock {
+	ids := adapter.NewIDGeneratorMock(prefix)
+	ids.Seed = handledMessageSeed
+
+	return ids
+}
+
+// handledMessageSeed is the UUID of the message handled with the context, along with
+// the name of its handler, if any.
+func handledMessageSeed(ctx context.Context) string {
+	causationID := asyncMiddleware.CausationIDFromContext(ctx)
+	if causationID == "" {
+		return ""
+	}
+
+	return causationID + "/" + message.HandlerNameFromCtx(ctx)
+}
@@ -0,0 +1,257 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"tickets/adapter"
+	"tickets/decorator"
+	"tickets/service"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/lithammer/shortuuid"
+)
+
+const (
+	scenarioTimeout  = 10 * time.Second
+	topicMetadataKey = "testkit_topic"
+)
+
+// Scenario tests the handlers in Given/When/Then steps, on a service running in memory.
+// Each step waits until the messages it causes are handled, so no polling is needed.
+type Scenario struct {
+	t          testing.TB
+	Service    *Service
+	eventBus   *cqrs.EventBus
+	commandBus *cqrs.CommandBus
+
+	// whenFrom is the index of the first message published by the When step.
+	whenFrom int
+}
+
+// Expectation checks the outcome of the When step.
+type Expectation func(t testing.TB, outcome Outcome)
+
+// Outcome holds the messages published since the When step started. The mocks and
+// repositories are the ones of the service, so they also hold what the Given steps did.
+type Outcome struct {
+	Published    []PublishedMessage
+	Mocks        adapter.ClientMocks
+	Repositories adapter.RepositoryMocks
+}
+
+type PublishedMessage struct {
+	Topic    string
+	UUID     string
+	Name     string
+	Payload  []byte
+	Metadata map[string]string
+}
+
+func NewScenario(t testing.TB, options service.Options) *Scenario {
+	t.Helper()
+
+	// The events of the steps are published straight to the broker, without any prefix.
+	options.Namespace = ""
+	svc := StartInMemory(t, options)
+
+	var ids adapter.IDGenerator = adapter.ShortUUIDGenerator{}
+	if options.IDs != nil {
+		ids = options.IDs
+	}
+
+	publisher := decorator.DecorateWithCorrelationPublisherDecorator(svc.Broker)
+	eventBus, err := adapter.NewEventBus(publisher, ids)
+	if err != nil {
+		t.Fatalf("unable to create event bus: %v", err)
+	}
+	commandBus, err := adapter.NewCommandBus(publisher, ids)
+	if err != nil {
+		t.Fatalf("unable to create command bus: %v", err)
+	}
+
+	return &Scenario{
+		t:          t,
+		Service:    svc,
+		eventBus:   eventBus,
+		commandBus: commandBus,
+	}
+}
+
+// Given publishes the events that already happened and waits until they are handled.
+func (s *Scenario) Given(events ...any) *Scenario {
+	s.t.Helper()
+
+	s.publish(events)
+	s.waitIdle()
+
+	return s
+}
+
+// When publishes the events under test and waits until they are handled.
+func (s *Scenario) When(events ...any) *Scenario {
+	s.t.Helper()
+
+	s.whenFrom = len(s.Service.Broker.Published())
+	s.publish(events)
+	s.waitIdle()
+
+	return s
+}
+
+// WhenCommand sends the commands under test and waits until they are handled.
+func (s *Scenario) WhenCommand(commands ...any) *Scenario {
+	s.t.Helper()
+
+	s.whenFrom = len(s.Service.Broker.Published())
+	s.send(commands)
+	s.waitIdle()
+
+	return s
+}
+
+// WhenHTTP calls the tickets API and waits until the messages it causes are handled.
+func (s *Scenario) WhenHTTP(call func(ctx context.Context, client *Client) error) *Scenario {
+	s.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scenarioTimeout)
+	defer cancel()
+
+	s.whenFrom = len(s.Service.Broker.Published())
+	err := call(ctx, s.Service.Client)
+	if err != nil {
+		s.t.Fatalf("HTTP call failed: %v", err)
+	}
+	s.waitIdle()
+
+	return s
+}
+
+// Then checks the outcome of the When step.
+func (s *Scenario) Then(expectations ...Expectation) *Scenario {
+	s.t.Helper()
+
+	published := s.Service.Broker.Published()[s.whenFrom:]
+	outcome := Outcome{
+		Published:    make([]PublishedMessage, 0, len(published)),
+		Mocks:        s.Service.Mocks,
+		Repositories: s.Service.Repositories,
+	}
+	for _, msg := range published {
+		outcome.Published = append(outcome.Published, PublishedMessage{
+			Topic:    msg.Metadata.Get(topicMetadataKey),
+			UUID:     msg.UUID,
+			Name:     msg.Metadata.Get(adapter.TypeMetadataKey),
+			Payload:  msg.Payload,
+			Metadata: msg.Metadata,
+		})
+	}
+
+	for _, expectation := range expectations {
+		expectation(s.t, outcome)
+	}
+
+	return s
+}
+
+func (s *Scenario) publish(events []any) {
+	s.t.Helper()
+
+	ctx := log.ContextWithCorrelationID(context.Background(), "scenario_"+shortuuid.New())
+	for _, event := range events {
+		err := s.eventBus.Publish(ctx, event)
+		if err != nil {
+			s.t.Fatalf("unable to publish %s: %v", cqrs.StructName(event), err)
+		}
+	}
+}
+
+func (s *Scenario) send(commands []any) {
+	s.t.Helper()
+
+	ctx := log.ContextWithCorrelationID(context.Background(), "scenario_"+shortuuid.New())
+	for _, command := range commands {
+		err := s.commandBus.Send(ctx, command)
+		if err != nil {
+			s.t.Fatalf("unable to send %s: %v", cqrs.StructName(command), err)
+		}
+	}
+}
+
+func (s *Scenario) waitIdle() {
+	s.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scenarioTimeout)
+	defer cancel()
+
+	err := s.Service.Broker.WaitIdle(ctx)
+	if err != nil {
+		s.t.Fatalf("messages not handled: %v", err)
+	}
+}
+
+// Published expects an event or command of type T to be published, matching match when it is set.
+func Published[T any](match func(message T) bool) Expectation {
+	return func(t testing.TB, outcome Outcome) {
+		t.Helper()
+
+		for _, message := range PublishedOf[T](t, outcome) {
+			if match == nil || match(message) {
+				return
+			}
+		}
+		t.Errorf("no matching %s published, published: %v", cqrs.StructName(new(T)), outcome.names())
+	}
+}
+
+// NotPublished expects no event or command of type T to be published.
+func NotPublished[T any]() Expectation {
+	return func(t testing.TB, outcome Outcome) {
+		t.Helper()
+
+		if messages := PublishedOf[T](t, outcome); len(messages) > 0 {
+			t.Errorf("%s published %d times, expected none", cqrs.StructName(new(T)), len(messages))
+		}
+	}
+}
+
+// Mocks checks the calls done to the clients since the service started, Given steps included.
+func Mocks(check func(t testing.TB, mocks adapter.ClientMocks)) Expectation {
+	return func(t testing.TB, outcome Outcome) {
+		t.Helper()
+
+		check(t, outcome.Mocks)
+	}
+}
+
+// PublishedOf decodes the published events or commands of type T, in order.
+func PublishedOf[T any](t testing.TB, outcome Outcome) []T {
+	t.Helper()
+
+	name := cqrs.StructName(new(T))
+	var messages []T
+	for _, published := range outcome.Published {
+		if published.Name != name {
+			continue
+		}
+
+		var message T
+		err := json.Unmarshal(published.Payload, &message)
+		if err != nil {
+			t.Fatalf("unable to decode %s %s: %v", name, published.UUID, err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+func (o Outcome) names() []string {
+	names := make([]string, 0, len(o.Published))
+	for _, published := range o.Published {
+		names = append(names, published.Name)
+	}
+	return names
+}
@@ -0,0 +1,186 @@
+// Package testkit runs isolated instances of the service for component tests.
+package testkit
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"tickets/adapter"
+	"tickets/service"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/lithammer/shortuuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	startTimeout = 10 * time.Second
+	stopTimeout  = 10 * time.Second
+
+	removeNamespaceBatch = 100
+)
+
+// Service is a running instance of the service with mocked clients and repositories.
+type Service struct {
+	Client       *Client
+	Mocks        adapter.ClientMocks
+	Repositories adapter.RepositoryMocks
+
+	// Namespace prefixes the streams, consumer groups and Redis keys of the instance.
+	Namespace string
+
+	// Broker and Scheduler are only set when the service runs in memory.
+	Broker    *Broker
+	Scheduler *adapter.MessageSchedulerMock
+}
+
+// Start runs the service on a random port, with its own streams, consumer groups and keys in
+// the Redis at REDIS_ADDR, so many tests can run it in parallel. The service is stopped,
+// and its keys removed, when the test finishes.
+func Start(t testing.TB, options service.Options) *Service {
+	t.Helper()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_ADDR"),
+	})
+	options.Namespace = cmp.Or(options.Namespace, "testkit-"+shortuuid.New())
+
+	// Cleanups run in reverse order, so the service stops before its keys are removed.
+	t.Cleanup(func() {
+		removeNamespace(t, rdb, options.Namespace)
+		_ = rdb.Close()
+	})
+
+	return start(t, rdb, options)
+}
+
+// StartInMemory runs the service on a random port, without Redis: messages go through
+// an in-memory Broker, and the booking processes and sent effects are kept in memory.
+// Delayed messages are kept by the Scheduler and never released.
+//
+// The Broker gives every consumer all the messages, so the handlers can't run several consumers.
+func StartInMemory(t testing.TB, options service.Options) *Service {
+	t.Helper()
+
+	for name, concurrency := range options.HandlersConcurrency {
+		if concurrency.Consumers > 1 {
+			t.Fatalf("%s runs %d consumers, but the in-memory broker only supports one", name, concurrency.Consumers)
+		}
+	}
+
+	broker := NewBroker(log.NewWatermill(logrus.NewEntry(logrus.StandardLogger())))
+	scheduler := adapter.NewMessageSchedulerMock()
+	bookingProcesses := adapter.NewBookingProcessRepositoryMock()
+	if options.Clock != nil {
+		bookingProcesses.Clock = options.Clock
+	}
+
+	options.Infrastructure = &service.Infrastructure{
+		Publisher: broker,
+		Subscribers: func(consumerGroup string) (message.Subscriber, error) {
+			return broker, nil
+		},
+		Scheduler:        scheduler,
+		BookingProcesses: bookingProcesses,
+		SentEffects:      adapter.NewDedupeStoreMock(),
+	}
+
+	t.Cleanup(func() {
+		_ = broker.Shutdown()
+	})
+
+	svc := start(t, nil, options)
+	svc.Broker = broker
+	svc.Scheduler = scheduler
+
+	return svc
+}
+
+func start(t testing.TB, rdb *redis.Client, options service.Options) *Service {
+	t.Helper()
+
+	options.HTTPAddr = cmp.Or(options.HTTPAddr, "127.0.0.1:0")
+
+	mocks := adapter.NewClientsMock()
+	repositories := adapter.NewRepositoriesMock()
+
+	// The mocks follow the clock and IDs of the service, so their outcome is deterministic too.
+	if options.Clock != nil {
+		mocks.Receipts.Clock = options.Clock
+	}
+	if options.IDs != nil {
+		mocks.Receipts.IDs = options.IDs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc := service.New(
+		ctx,
+		rdb,
+		logrus.NewEntry(logrus.StandardLogger()),
+		mocks.Clients(),
+		repositories.Repositories(),
+		options,
+	)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- svc.Run()
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+
+		select {
+		case err := <-stopped:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				t.Errorf("service stopped with error: %v", err)
+			}
+		case <-time.After(stopTimeout):
+			t.Errorf("service did not stop in %s", stopTimeout)
+		}
+	})
+
+	select {
+	case <-svc.Running():
+	case err := <-stopped:
+		t.Fatalf("service stopped before running: %v", err)
+	case <-time.After(startTimeout):
+		t.Fatalf("service did not start in %s", startTimeout)
+	}
+
+	return &Service{
+		Client:       NewClient("http://" + svc.HTTPAddr()),
+		Mocks:        mocks,
+		Repositories: repositories,
+		Namespace:    options.Namespace,
+	}
+}
+
+// removeNamespace deletes the keys of the namespace: its streams, along with their consumer
+// groups, and the keys of the scheduler and stores. It scans the keys, so it doesn't block
+// the Redis shared with the other tests.
+func removeNamespace(t testing.TB, rdb *redis.Client, namespace string) {
+	ctx := context.Background()
+
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, namespace+".*", removeNamespaceBatch).Result()
+		if err == nil && len(keys) > 0 {
+			err = rdb.Del(ctx, keys...).Err()
+		}
+		if err != nil {
+			t.Logf("unable to remove the keys of namespace %s: %v", namespace, err)
+			return
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return
+		}
+	}
+}
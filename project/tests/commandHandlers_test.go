@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"testing"
+	"tickets/adapter"
+	"tickets/service"
+	"tickets/testkit"
+
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandHandlers_issueReceipt(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		WhenCommand(adapter.IssueReceipt{
+			TicketID:       ticketID,
+			Price:          adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+			IdempotencyKey: "issue-receipt-" + ticketID,
+		}).
+		Then(
+			testkit.Published(func(event adapter.TicketReceiptIssued) bool {
+				return event.TicketID == ticketID && event.ReceiptNumber != ""
+			}),
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Equal(t, []adapter.IssueReceiptRequest{{
+					TicketID:       ticketID,
+					Price:          adapter.Money{Amount: "50.00", Currency: "USD"},
+					IdempotencyKey: "issue-receipt-" + ticketID,
+				}}, mocks.Receipts.Issued())
+			}),
+		)
+}
+
+func TestCommandHandlers_voidReceipt(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		WhenCommand(adapter.VoidReceipt{
+			TicketID: ticketID,
+			Reason:   "ticket canceled",
+		}).
+		Then(
+			testkit.Published(func(event adapter.TicketReceiptVoided) bool {
+				return event.TicketID == ticketID
+			}),
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Equal(t, []adapter.VoidReceiptRequest{{
+					TicketID: ticketID,
+					Reason:   "ticket canceled",
+				}}, mocks.Receipts.Voided())
+			}),
+		)
+}
+
+func TestCommandHandlers_appendTicketRow(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+	row := []string{ticketID, "truman@capote.com", "50.00", "USD"}
+
+	testkit.NewScenario(t, service.Options{}).
+		WhenCommand(adapter.AppendTicketRow{
+			TicketID:  ticketID,
+			SheetName: printSheet,
+			Row:       row,
+		}).
+		Then(
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Equal(t, [][]string{row}, mocks.Spreadsheets.RowsFor(printSheet))
+			}),
+		)
+}
+
+func TestCommandHandlers_appendTicketRowOnce(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+	command := adapter.AppendTicketRow{
+		TicketID:  ticketID,
+		SheetName: refoundSheet,
+		Row:       []string{ticketID, "truman@capote.com", "50.00", "USD"},
+		EventType: "TicketBookingCanceled",
+	}
+
+	// The command is sent again, as when the event causing it is redelivered.
+	testkit.NewScenario(t, service.Options{}).
+		WhenCommand(command, command).
+		Then(
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Len(t, mocks.Spreadsheets.RowsFor(refoundSheet), 1)
+			}),
+		)
+}
@@ -1,15 +1,15 @@
 package tests_test
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
+	"context"
 	"testing"
 	"tickets/adapter"
+	"tickets/domain/ticket"
 	"tickets/service"
+	"tickets/testkit"
 	"time"
 
-	"github.com/lithammer/shortuuid"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,206 +19,88 @@ const (
 	refoundSheet = "tickets-to-refund"
 )
 
-var confirmedTicket = TicketStatus{
-	TicketID: shortuuid.New(),
-	Status:   "confirmed",
-	Price: Money{
-		Amount:   "50.00",
-		Currency: "USD",
-	},
-	Email:     "truman@capote.com",
-	BookingID: shortuuid.New(),
-}
+func TestComponent(t *testing.T) {
+	t.Parallel()
 
-var canceledTicket = TicketStatus{
-	TicketID: shortuuid.New(),
-	Status:   "canceled",
-	Price: Money{
-		Amount:   "50.00",
-		Currency: "USD",
-	},
-	Email:     "truman@capote.com",
-	BookingID: shortuuid.New(),
-}
+	svc := testkit.Start(t, service.Options{})
 
-func TestComponent(t *testing.T) {
+	confirmedTicket := newTicket("confirmed")
+	canceledTicket := newTicket("canceled")
 
-	mocks := runService(t)
-	waitForHttpServer(t)
-	sendTicketsStatus(t, TicketsStatusRequest{
-		Tickets: []TicketStatus{
-			confirmedTicket,
-			canceledTicket,
-		},
-	})
-	assertReceiptForTicketIssued(t, mocks.Receipts, confirmedTicket)
-	assertSpreadsheetRowForTicketIssued(t, mocks.Spreadsheets, confirmedTicket)
-	assertSpreadsheetRowForTicketCanceled(t, mocks.Spreadsheets, canceledTicket)
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-func runService(t *testing.T) adapter.ClientMocks {
-	t.Helper()
-	svc, serviceMocks := service.DefaultMock()
-	go func() {
-		assert.NoError(t, svc.Run())
-	}()
+	err := svc.Client.SendTicketsStatus(ctx, confirmedTicket, canceledTicket)
+	require.NoError(t, err)
 
-	return serviceMocks
+	assertReceiptForTicketIssued(ctx, t, svc.Mocks.Receipts, confirmedTicket)
+	assertReceiptForTicketStored(ctx, t, svc.Client, confirmedTicket)
+	assertSpreadsheetRowForTicket(ctx, t, svc.Mocks.Spreadsheets, printSheet, confirmedTicket)
+	assertSpreadsheetRowForTicket(ctx, t, svc.Mocks.Spreadsheets, refoundSheet, canceledTicket)
+	assertPaymentForTicketRefunded(ctx, t, svc.Mocks.Payments, canceledTicket)
 }
 
-func waitForHttpServer(t *testing.T) {
-	t.Helper()
-
-	require.EventuallyWithT(
-		t,
-		func(t *assert.CollectT) {
-			resp, err := http.Get("http://localhost:8080/health")
-			if !assert.NoError(t, err) {
-				return
-			}
-			defer resp.Body.Close()
-
-			if assert.Less(t, resp.StatusCode, 300, "API not ready, http status: %d", resp.StatusCode) {
-				return
-			}
+func newTicket(status string) ticket.Ticket {
+	return ticket.Ticket{
+		// The ticket IDs are UUIDs, as in the receipts table.
+		ID:            uuid.NewString(),
+		Status:        status,
+		CustomerEmail: "truman@capote.com",
+		Price: ticket.Money{
+			Amount:   "50.00",
+			Currency: "USD",
 		},
-		time.Second*10,
-		time.Millisecond*50,
-	)
+	}
 }
 
-func assertReceiptForTicketIssued(t *testing.T, receiptsService *adapter.ReceiptsServiceMock, ticket TicketStatus) {
-	assert.EventuallyWithT(
-		t,
-		func(collectT *assert.CollectT) {
-			issuedReceipts := len(receiptsService.IssuedReceipts)
-			t.Log("issued receipts", issuedReceipts)
-
-			assert.Greater(collectT, issuedReceipts, 0, "no receipts issued")
-		},
-		10*time.Second,
-		100*time.Millisecond,
-	)
+func assertReceiptForTicketIssued(ctx context.Context, t *testing.T, receiptsService *adapter.ReceiptsServiceMock, ticket ticket.Ticket) {
+	t.Helper()
 
-	var receipt adapter.IssueReceiptRequest
-	var ok bool
-	for _, issuedReceipt := range receiptsService.IssuedReceipts {
-		if issuedReceipt.TicketID != ticket.TicketID {
-			continue
-		}
-		receipt = issuedReceipt
-		ok = true
-		break
-	}
-	require.Truef(t, ok, "receipt for ticket %s not found", ticket.TicketID)
+	receipt, err := receiptsService.WaitForReceipt(ctx, ticket.ID)
+	require.NoErrorf(t, err, "receipt for ticket %s not found", ticket.ID)
 
-	assert.Equal(t, ticket.TicketID, receipt.TicketID)
+	assert.Equal(t, ticket.ID, receipt.TicketID)
 	assert.Equal(t, ticket.Price.Amount, receipt.Price.Amount)
 	assert.Equal(t, ticket.Price.Currency, receipt.Price.Currency)
 }
 
-func assertSpreadsheetRowForTicketIssued(t *testing.T, spreadsheetsAPI *adapter.SpreadsheetsAPIMock, ticket TicketStatus) {
-	assert.EventuallyWithT(
+func assertReceiptForTicketStored(ctx context.Context, t *testing.T, client *testkit.Client, ticket ticket.Ticket) {
+	t.Helper()
+
+	var receipt adapter.IssuedReceipt
+	require.EventuallyWithT(
 		t,
 		func(collectT *assert.CollectT) {
-			appendedRows := len(spreadsheetsAPI.AppendedRows[printSheet])
-			t.Logf("[%s]appended Rows %d", printSheet, appendedRows)
-
-			assert.Greater(collectT, appendedRows, 0, "no appended rows to ", printSheet)
+			var err error
+			receipt, err = client.GetReceipt(ctx, ticket.ID)
+			assert.NoError(collectT, err, "receipt not stored")
 		},
 		10*time.Second,
 		100*time.Millisecond,
 	)
 
-	var row []string
-	var ok bool
-	for _, appendedRow := range spreadsheetsAPI.AppendedRows[printSheet] {
-		if appendedRow[0] != ticket.TicketID {
-			continue
-		}
-		row = appendedRow
-		ok = true
-		break
-	}
-	require.Truef(t, ok, "row for ticket %s not found", ticket.TicketID)
-
-	assert.Equal(t, ticket.TicketID, row[0])
-	assert.Equal(t, ticket.Email, row[1])
-	assert.Equal(t, ticket.Price.Amount, row[2])
-	assert.Equal(t, ticket.Price.Currency, row[3])
+	assert.Equal(t, ticket.ID, receipt.TicketID)
+	assert.NotEmpty(t, receipt.ReceiptNumber)
 }
 
-func assertSpreadsheetRowForTicketCanceled(t *testing.T, spreadsheetsAPI *adapter.SpreadsheetsAPIMock, ticket TicketStatus) {
-	assert.EventuallyWithT(
-		t,
-		func(collectT *assert.CollectT) {
-			appendedRows := len(spreadsheetsAPI.AppendedRows[refoundSheet])
-			t.Logf("[%s]appended Rows %d", refoundSheet, appendedRows)
-			assert.Greater(collectT, appendedRows, 0, "no appended rows to ", refoundSheet)
-		},
-		10*time.Second,
-		100*time.Millisecond,
-	)
+func assertSpreadsheetRowForTicket(ctx context.Context, t *testing.T, spreadsheetsAPI *adapter.SpreadsheetsAPIMock, sheet string, ticket ticket.Ticket) {
+	t.Helper()
 
-	var row []string
-	var ok bool
-	for _, appendedRow := range spreadsheetsAPI.AppendedRows[refoundSheet] {
-		if appendedRow[0] != ticket.TicketID {
-			continue
-		}
-		row = appendedRow
-		ok = true
-		break
-	}
-	require.Truef(t, ok, "row for ticket %s not found", ticket.TicketID)
+	row, err := spreadsheetsAPI.WaitForRow(ctx, sheet, ticket.ID)
+	require.NoErrorf(t, err, "[%s] row for ticket %s not found", sheet, ticket.ID)
 
-	assert.Equal(t, ticket.TicketID, row[0])
-	assert.Equal(t, ticket.Email, row[1])
+	require.Len(t, row, 4)
+	assert.Equal(t, ticket.ID, row[0])
+	assert.Equal(t, ticket.CustomerEmail, row[1])
 	assert.Equal(t, ticket.Price.Amount, row[2])
 	assert.Equal(t, ticket.Price.Currency, row[3])
 }
 
-type TicketsStatusRequest struct {
-	Tickets []TicketStatus `json:"tickets"`
-}
-
-type TicketStatus struct {
-	TicketID  string `json:"ticket_id"`
-	Status    string `json:"status"`
-	Price     Money  `json:"price"`
-	Email     string `json:"customer_email"`
-	BookingID string `json:"booking_id"`
-}
-
-type Money struct {
-	Amount   string `json:"amount"`
-	Currency string `json:"currency"`
-}
-
-func sendTicketsStatus(t *testing.T, req TicketsStatusRequest) {
+func assertPaymentForTicketRefunded(ctx context.Context, t *testing.T, paymentsService *adapter.PaymentsServiceMock, ticket ticket.Ticket) {
 	t.Helper()
 
-	payload, err := json.Marshal(req)
-	require.NoError(t, err)
-
-	correlationID := shortuuid.New()
+	refund, err := paymentsService.WaitForRefund(ctx, ticket.ID)
+	require.NoErrorf(t, err, "refund for ticket %s not found", ticket.ID)
 
-	ticketIDs := make([]string, 0, len(req.Tickets))
-	for _, ticket := range req.Tickets {
-		ticketIDs = append(ticketIDs, ticket.TicketID)
-	}
-
-	httpReq, err := http.NewRequest(
-		http.MethodPost,
-		"http://localhost:8080/tickets-status",
-		bytes.NewBuffer(payload),
-	)
-	require.NoError(t, err)
-
-	httpReq.Header.Set("Correlation-ID", correlationID)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	require.NoError(t, err)
-	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, refund.DeduplicationID)
 }
@@ -0,0 +1,175 @@
+package tests_test
+
+import (
+	"context"
+	"testing"
+	"tickets/adapter"
+	"tickets/decorator"
+	"tickets/service"
+	"tickets/testkit"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	watermillMessage "github.com/ThreeDotsLabs/watermill/message"
+	"github.com/lithammer/shortuuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	dryRun := startDryRun(t)
+
+	eventBus, err := adapter.NewEventBus(dryRun.publisher, adapter.ShortUUIDGenerator{})
+	require.NoError(t, err)
+
+	ticketID := shortuuid.New()
+	err = eventBus.Publish(context.Background(), adapter.TicketBookingConfirmed{
+		TicketID:      ticketID,
+		CustomerEmail: "truman@capote.com",
+		Price:         adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+	})
+	require.NoError(t, err)
+
+	report := dryRun.report(t)
+	// The commands and events are counted instead of published, so only their first handlers run.
+	assert.Equal(t, 1, report.Published["IssueReceipt"])
+	assert.Equal(t, 1, report.Published["AppendTicketRow"])
+	assert.Equal(t, 1, report.Published["TicketPrinted"])
+	assert.Equal(t, 1, report.Scheduled["TicketBookingTimeoutElapsed"])
+	assert.Equal(t, 1, report.Notifications)
+	assert.Equal(t, 2, report.Files)
+
+	// Nothing left the service.
+	assert.Len(t, dryRun.broker.Published(), 1)
+	dryRun.assertNoSideEffects(t)
+}
+
+func TestDryRun_commands(t *testing.T) {
+	t.Parallel()
+
+	dryRun := startDryRun(t)
+
+	commandBus, err := adapter.NewCommandBus(dryRun.publisher, adapter.ShortUUIDGenerator{})
+	require.NoError(t, err)
+
+	ticketID := shortuuid.New()
+	err = commandBus.Send(context.Background(), adapter.IssueReceipt{
+		TicketID:       ticketID,
+		Price:          adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+		IdempotencyKey: ticketID,
+	})
+	require.NoError(t, err)
+	err = commandBus.Send(context.Background(), adapter.AppendTicketRow{
+		TicketID:  ticketID,
+		SheetName: "tickets-to-print",
+		Row:       []string{ticketID, "truman@capote.com", "50.00", "USD"},
+		EventType: "TicketBookingConfirmed",
+	})
+	require.NoError(t, err)
+
+	report := dryRun.report(t)
+	assert.Equal(t, 1, report.ReceiptsIssued)
+	// The receipt is stored on TicketReceiptIssued, which is counted instead of handled.
+	assert.Equal(t, 1, report.Published["TicketReceiptIssued"])
+	assert.Zero(t, report.ReceiptsStored)
+	assert.Equal(t, map[string]int{"tickets-to-print": 1}, report.Rows)
+
+	dryRun.assertNoSideEffects(t)
+}
+
+type dryRunService struct {
+	svc          service.Service
+	broker       *testkit.Broker
+	publisher    watermillMessage.Publisher
+	mocks        adapter.ClientMocks
+	repositories adapter.RepositoryMocks
+	scheduler    *adapter.MessageSchedulerMock
+}
+
+// startDryRun runs the service in dry-run mode on an in-memory broker. The side effects
+// of a mistaken dry run would land in the given clients, repositories and scheduler.
+func startDryRun(t *testing.T) dryRunService {
+	t.Helper()
+
+	broker := testkit.NewBroker(log.NewWatermill(logrus.NewEntry(logrus.StandardLogger())))
+	t.Cleanup(func() {
+		_ = broker.Shutdown()
+	})
+
+	dryRun := dryRunService{
+		broker:       broker,
+		publisher:    decorator.DecorateWithCorrelationPublisherDecorator(broker),
+		mocks:        adapter.NewClientsMock(),
+		repositories: adapter.NewRepositoriesMock(),
+		scheduler:    adapter.NewMessageSchedulerMock(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dryRun.svc = service.New(
+		ctx,
+		nil,
+		logrus.NewEntry(logrus.StandardLogger()),
+		dryRun.mocks.Clients(),
+		dryRun.repositories.Repositories(),
+		service.Options{
+			HTTPAddr: "127.0.0.1:0",
+			DryRun:   true,
+			Infrastructure: &service.Infrastructure{
+				Publisher: broker,
+				Subscribers: func(consumerGroup string) (watermillMessage.Subscriber, error) {
+					assert.Contains(t, consumerGroup, "dry-run.")
+					return broker, nil
+				},
+				Scheduler:        dryRun.scheduler,
+				BookingProcesses: adapter.NewBookingProcessRepositoryMock(),
+				SentEffects:      adapter.NewDedupeStoreMock(),
+			},
+		},
+	)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- dryRun.svc.Run()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+
+	select {
+	case <-dryRun.svc.Running():
+	case <-time.After(10 * time.Second):
+		t.Fatal("service did not start")
+	}
+
+	return dryRun
+}
+
+// report waits for the handlers to be done with the published messages.
+func (s dryRunService) report(t *testing.T) service.DryRunReport {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.broker.WaitIdle(ctx))
+
+	report, ok := s.svc.DryRunReport()
+	require.True(t, ok)
+
+	return report
+}
+
+func (s dryRunService) assertNoSideEffects(t *testing.T) {
+	t.Helper()
+
+	assert.Empty(t, s.scheduler.ScheduledMessages())
+	assert.Empty(t, s.mocks.Receipts.Issued())
+	assert.Empty(t, s.mocks.Spreadsheets.RowsFor("tickets-to-print"))
+	assert.Empty(t, s.mocks.Spreadsheets.RowsFor("tickets-to-refund"))
+	assert.Empty(t, s.mocks.Notifier.Sent())
+	assert.Empty(t, s.mocks.Files.Names())
+	assert.Empty(t, s.repositories.Receipts.Stored())
+}
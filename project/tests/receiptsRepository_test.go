@@ -0,0 +1,61 @@
+package tests_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReceiptsPostgresRepository needs the migrations applied to the POSTGRES_URL database.
+func TestReceiptsPostgresRepository(t *testing.T) {
+	t.Parallel()
+
+	db, err := sqlx.Open("postgres", os.Getenv("POSTGRES_URL"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	repository := adapter.NewReceiptsPostgresRepository(db)
+	ctx := context.Background()
+
+	// The ticket IDs are UUIDs in the database.
+	ticketID := watermill.NewUUID()
+	receipt := adapter.IssuedReceipt{
+		TicketID:      ticketID,
+		ReceiptNumber: "receipt-1",
+		IssuedAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	_, err = repository.FindByTicketID(ctx, ticketID)
+	assert.ErrorIs(t, err, adapter.ErrReceiptNotFound)
+
+	require.NoError(t, repository.Add(ctx, receipt))
+
+	// A redelivered receipt doesn't replace the stored one.
+	require.NoError(t, repository.Add(ctx, adapter.IssuedReceipt{
+		TicketID:      ticketID,
+		ReceiptNumber: "receipt-2",
+		IssuedAt:      receipt.IssuedAt.Add(time.Hour),
+	}))
+
+	found, err := repository.FindByTicketID(ctx, ticketID)
+	require.NoError(t, err)
+	assert.Equal(t, receipt.TicketID, found.TicketID)
+	assert.Equal(t, receipt.ReceiptNumber, found.ReceiptNumber)
+	assert.True(t, receipt.IssuedAt.Equal(found.IssuedAt))
+
+	// A ticket ID that is not a UUID is rejected for good, instead of failing in the database.
+	err = repository.Add(ctx, adapter.IssuedReceipt{TicketID: "not-a-uuid", ReceiptNumber: "receipt-3"})
+	assert.ErrorIs(t, err, adapter.ErrInvalidTicketID)
+
+	_, err = repository.FindByTicketID(ctx, "not-a-uuid")
+	assert.ErrorIs(t, err, adapter.ErrReceiptNotFound)
+}
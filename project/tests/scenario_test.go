@@ -0,0 +1,284 @@
+package tests_test
+
+import (
+	"context"
+	"testing"
+	"tickets/adapter"
+	"tickets/middleware/asyncMiddleware"
+	"tickets/service"
+	"tickets/testkit"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lithammer/shortuuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenario_bookingCanceledAfterConfirmation(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+	price := adapter.MoneyPayload{Amount: "50.00", Currency: "USD"}
+
+	testkit.NewScenario(t, service.Options{}).
+		Given(adapter.TicketBookingConfirmed{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         price,
+		}).
+		When(adapter.TicketBookingCanceled{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         price,
+		}).
+		Then(
+			testkit.Published(func(command adapter.VoidReceipt) bool {
+				return command.TicketID == ticketID
+			}),
+			testkit.Published(func(event adapter.TicketRefunded) bool {
+				return event.TicketID == ticketID
+			}),
+			testkit.NotPublished[adapter.IssueReceipt](),
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				_, err := mocks.Payments.WaitForRefund(context.Background(), ticketID)
+				assert.NoError(t, err)
+				assert.Len(t, mocks.Receipts.Voided(), 1)
+				assert.Len(t, mocks.Spreadsheets.RowsFor(refoundSheet), 1)
+			}),
+		)
+}
+
+func TestScenario_ticketsStatusRequest(t *testing.T) {
+	t.Parallel()
+
+	confirmedTicket := newTicket("confirmed")
+
+	testkit.NewScenario(t, service.Options{}).
+		WhenHTTP(func(ctx context.Context, client *testkit.Client) error {
+			return client.SendTicketsStatus(ctx, confirmedTicket)
+		}).
+		Then(
+			testkit.Published(func(event adapter.TicketBookingConfirmed) bool {
+				return event.TicketID == confirmedTicket.ID
+			}),
+			testkit.Published(func(event adapter.TicketReceiptIssued) bool {
+				return event.TicketID == confirmedTicket.ID
+			}),
+			testkit.Published(func(event adapter.TicketPrinted) bool {
+				return event.TicketID == confirmedTicket.ID
+			}),
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Len(t, mocks.Receipts.Issued(), 1)
+				assert.Len(t, mocks.Spreadsheets.RowsFor(printSheet), 1)
+				assert.Len(t, mocks.Notifier.Sent(), 1)
+			}),
+		)
+}
+
+func TestScenario_handlersTolerateRedeliveries(t *testing.T) {
+	t.Parallel()
+
+	chaos := asyncMiddleware.NewChaos(asyncMiddleware.ChaosConfig{
+		ReplayProbability: 1,
+		DelayProbability:  0.5,
+		MaxDelay:          5 * time.Millisecond,
+		Seed:              46,
+	})
+	confirmedTicket := newTicket("confirmed")
+	canceledTicket := newTicket("canceled")
+
+	testkit.NewScenario(t, service.Options{Chaos: chaos}).
+		WhenHTTP(func(ctx context.Context, client *testkit.Client) error {
+			return client.SendTicketsStatus(ctx, confirmedTicket, canceledTicket)
+		}).
+		Then(
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Len(t, mocks.Receipts.Issued(), 1)
+				assert.Len(t, mocks.Spreadsheets.RowsFor(printSheet), 1)
+				assert.Len(t, mocks.Spreadsheets.RowsFor(refoundSheet), 1)
+				assert.Len(t, mocks.Payments.Refunded(), 1)
+				assert.Len(t, mocks.Notifier.Sent(), 2)
+			}),
+		)
+
+	t.Log(chaos.Report())
+}
+
+func TestScenario_deterministicClockAndIDs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	bookingTimeout := time.Minute
+	ticketID := shortuuid.New()
+
+	scenario := testkit.NewScenario(t, service.Options{
+		BookingTimeout: bookingTimeout,
+		Clock:          adapter.NewClockMock(now),
+		IDs:            testkit.NewIDGeneratorMock("id"),
+	})
+
+	scenario.
+		When(adapter.TicketBookingConfirmed{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+		}).
+		Then(
+			testkit.Published(func(event adapter.TicketReceiptIssued) bool {
+				return event.TicketID == ticketID &&
+					event.IssuedAt.Equal(now) &&
+					event.ReceiptNumber == "id-1/issueReceiptHandler-1/issueReceiptCommandHandler-1"
+			}),
+			func(t testing.TB, outcome testkit.Outcome) {
+				uuids := map[string]string{}
+				for _, msg := range outcome.Published {
+					uuids[msg.Name] = msg.UUID
+				}
+
+				// The IDs follow the message and handler they are made in, whatever order the handlers run in.
+				assert.Equal(t, map[string]string{
+					"TicketBookingConfirmed": "id-1",
+					"IssueReceipt":           "id-1/issueReceiptHandler-1",
+					"TicketReceiptIssued":    "id-1/issueReceiptHandler-1/issueReceiptCommandHandler-2",
+					"AppendTicketRow":        "id-1/printTicketHandler-1",
+					"TicketPrinted":          "id-1/renderTicketHandler-1",
+					"TicketNotificationSent": "id-1/sendBookingConfirmationHandler-1",
+				}, uuids)
+			},
+		)
+
+	scheduled := scenario.Service.Scheduler.ScheduledMessages()
+	if assert.Len(t, scheduled, 1) {
+		assert.Equal(t, now.Add(bookingTimeout), scheduled[0].DeliverAt)
+		assert.Equal(t, "id-1/bookingProcessStartHandler-1", scheduled[0].Message.UUID)
+	}
+}
+
+func TestScenario_receiptIssuedAfterCancellationIsVoided(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		Given(adapter.TicketBookingCanceled{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+		}).
+		When(adapter.TicketReceiptIssued{
+			TicketID:      ticketID,
+			ReceiptNumber: "receipt-1",
+			IssuedAt:      time.Now(),
+		}).
+		Then(
+			testkit.Published(func(command adapter.VoidReceipt) bool {
+				return command.TicketID == ticketID
+			}),
+			testkit.Published(func(event adapter.TicketReceiptVoided) bool {
+				return event.TicketID == ticketID
+			}),
+			testkit.Mocks(func(t testing.TB, mocks adapter.ClientMocks) {
+				assert.Len(t, mocks.Receipts.Voided(), 1)
+			}),
+		)
+}
+
+func TestScenario_stuckBookingTimesOut(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		When(adapter.TicketBookingTimeoutElapsed{
+			TicketID: ticketID,
+		}).
+		Then(
+			testkit.Published(func(event adapter.TicketBookingTimedOut) bool {
+				return event.TicketID == ticketID && !event.ReceiptIssued && !event.TicketPrinted
+			}),
+		)
+}
+
+func TestScenario_completedBookingDoesNotTimeOut(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		Given(adapter.TicketBookingConfirmed{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+		}).
+		When(adapter.TicketBookingTimeoutElapsed{
+			TicketID: ticketID,
+		}).
+		Then(
+			testkit.NotPublished[adapter.TicketBookingTimedOut](),
+		)
+}
+
+func TestScenario_causationAndCorrelationIDs(t *testing.T) {
+	t.Parallel()
+
+	ticketID := shortuuid.New()
+
+	testkit.NewScenario(t, service.Options{}).
+		When(adapter.TicketBookingConfirmed{
+			TicketID:      ticketID,
+			CustomerEmail: "truman@capote.com",
+			Price:         adapter.MoneyPayload{Amount: "50.00", Currency: "USD"},
+		}).
+		Then(func(t testing.TB, outcome testkit.Outcome) {
+			byName := map[string]testkit.PublishedMessage{}
+			for _, msg := range outcome.Published {
+				byName[msg.Name] = msg
+			}
+
+			confirmed := byName["TicketBookingConfirmed"]
+			issueReceipt := byName["IssueReceipt"]
+			receiptIssued := byName["TicketReceiptIssued"]
+
+			// Each message is caused by the one consumed by the handler publishing it.
+			assert.Equal(t, confirmed.UUID, issueReceipt.Metadata[asyncMiddleware.CausationIDMetadataKey])
+			assert.Equal(t, issueReceipt.UUID, receiptIssued.Metadata[asyncMiddleware.CausationIDMetadataKey])
+
+			correlationID := confirmed.Metadata[asyncMiddleware.CorrelationIDMetadataKey]
+			assert.NotEmpty(t, correlationID)
+			assert.Equal(t, correlationID, issueReceipt.Metadata[asyncMiddleware.CorrelationIDMetadataKey])
+			assert.Equal(t, correlationID, receiptIssued.Metadata[asyncMiddleware.CorrelationIDMetadataKey])
+		})
+}
+
+func TestScenario_receiptIsStored(t *testing.T) {
+	t.Parallel()
+
+	ticketID := uuid.NewString()
+
+	testkit.NewScenario(t, service.Options{}).
+		When(adapter.TicketReceiptIssued{
+			TicketID:      ticketID,
+			ReceiptNumber: "receipt-1",
+			IssuedAt:      time.Now(),
+		}).
+		Then(func(t testing.TB, outcome testkit.Outcome) {
+			receipt, err := outcome.Repositories.Receipts.FindByTicketID(context.Background(), ticketID)
+			assert.NoError(t, err)
+			assert.Equal(t, "receipt-1", receipt.ReceiptNumber)
+		})
+}
+
+func TestScenario_receiptOfInvalidTicketIsDropped(t *testing.T) {
+	t.Parallel()
+
+	// The scenario waits until the event is acked, so it fails if the event is redelivered forever.
+	testkit.NewScenario(t, service.Options{}).
+		When(adapter.TicketReceiptIssued{
+			TicketID:      "not-a-uuid",
+			ReceiptNumber: "receipt-1",
+			IssuedAt:      time.Now(),
+		}).
+		Then(func(t testing.TB, outcome testkit.Outcome) {
+			assert.Empty(t, outcome.Repositories.Receipts.Stored())
+		})
+}
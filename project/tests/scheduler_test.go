@@ -0,0 +1,152 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"tickets/adapter"
+	"time"
+
+	"github.com/ThreeDotsLabs/go-event-driven/common/log"
+	"github.com/ThreeDotsLabs/watermill-redisstream/pkg/redisstream"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/lithammer/shortuuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schedulerNow is the fixed time of the test schedulers.
+var schedulerNow = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestMessageScheduler(t *testing.T) {
+	t.Parallel()
+
+	rdb, keyPrefix, scheduler, messages := newTestScheduler(t)
+
+	topic := "scheduler-test-" + shortuuid.New()
+	msg := message.NewMessage(shortuuid.New(), []byte(`{"ticket_id":"ticket-1"}`))
+	msg.SetContext(context.Background())
+
+	err := scheduler.Schedule(topic, msg, schedulerNow.Add(-time.Second))
+	require.NoError(t, err)
+
+	received := receive(t, messages(topic))
+	assert.Equal(t, msg.UUID, received.UUID)
+	assert.Equal(t, msg.Payload, received.Payload)
+
+	assertNotScheduled(t, rdb, keyPrefix, msg.UUID)
+}
+
+func TestMessageScheduler_releasesMessagesClaimedByCrashedScheduler(t *testing.T) {
+	t.Parallel()
+
+	rdb, keyPrefix, _, messages := newTestScheduler(t)
+
+	topic := "scheduler-test-" + shortuuid.New()
+	uuid := shortuuid.New()
+	member, err := json.Marshal(map[string]any{
+		"topic":    topic,
+		"uuid":     uuid,
+		"metadata": map[string]string{},
+		"payload":  []byte(`{"ticket_id":"ticket-1"}`),
+	})
+	require.NoError(t, err)
+
+	// Claimed an hour ago, by a scheduler that never released it.
+	err = rdb.ZAdd(context.Background(), keyPrefix+"scheduled-messages:claimed", redis.Z{
+		Score:  float64(schedulerNow.Add(-time.Hour).UnixMilli()),
+		Member: member,
+	}).Err()
+	require.NoError(t, err)
+
+	received := receive(t, messages(topic))
+	assert.Equal(t, uuid, received.UUID)
+
+	assertNotScheduled(t, rdb, keyPrefix, uuid)
+}
+
+// newTestScheduler returns a scheduler with its own keys, so it doesn't release
+// the messages of the other tests.
+func newTestScheduler(t *testing.T) (
+	rdb *redis.Client,
+	keyPrefix string,
+	scheduler *adapter.MessageScheduler,
+	subscribe func(topic string) <-chan *message.Message,
+) {
+	t.Helper()
+
+	rdb = redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_ADDR"),
+	})
+	keyPrefix = "scheduler-test-" + shortuuid.New() + "."
+	t.Cleanup(func() {
+		_ = rdb.Del(context.Background(), keyPrefix+"scheduled-messages", keyPrefix+"scheduled-messages:claimed").Err()
+		_ = rdb.Close()
+	})
+
+	logger := log.NewWatermill(logrus.NewEntry(logrus.StandardLogger()))
+	publisher, err := redisstream.NewPublisher(redisstream.PublisherConfig{Client: rdb}, logger)
+	require.NoError(t, err)
+
+	scheduler = adapter.NewMessageScheduler(rdb, publisher, logger, adapter.NewClockMock(schedulerNow), keyPrefix)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = scheduler.Run(ctx)
+	}()
+
+	subscribe = func(topic string) <-chan *message.Message {
+		subscriber, err := redisstream.NewSubscriber(redisstream.SubscriberConfig{
+			Client:        rdb,
+			ConsumerGroup: "scheduler-test",
+		}, logger)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = subscriber.Close()
+			_ = rdb.Del(context.Background(), topic).Err()
+		})
+
+		messages, err := subscriber.Subscribe(ctx, topic)
+		require.NoError(t, err)
+
+		return messages
+	}
+
+	return rdb, keyPrefix, scheduler, subscribe
+}
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		msg.Ack()
+		return msg
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "scheduled message not released")
+		return nil
+	}
+}
+
+func assertNotScheduled(t *testing.T, rdb *redis.Client, keyPrefix string, uuid string) {
+	t.Helper()
+
+	assert.EventuallyWithT(t, func(t *assert.CollectT) {
+		for _, key := range []string{keyPrefix + "scheduled-messages", keyPrefix + "scheduled-messages:claimed"} {
+			members, err := rdb.ZRange(context.Background(), key, 0, -1).Result()
+			require.NoError(t, err)
+
+			for _, member := range members {
+				var scheduled struct {
+					UUID string `json:"uuid"`
+				}
+				_ = json.Unmarshal([]byte(member), &scheduled)
+				assert.NotEqual(t, uuid, scheduled.UUID, "message still in %s", key)
+			}
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,80 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"tickets/service"
+	"tickets/testkit"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestkit_isolatesScheduledMessages(t *testing.T) {
+	t.Parallel()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_ADDR"),
+	})
+	t.Cleanup(func() {
+		_ = rdb.Close()
+	})
+
+	var namespaces []string
+	t.Run("services", func(t *testing.T) {
+		// The booking timeout keeps the messages scheduled until the services stop.
+		options := service.Options{BookingTimeout: time.Hour}
+		first := testkit.Start(t, options)
+		second := testkit.Start(t, options)
+		namespaces = []string{first.Namespace, second.Namespace}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		confirmedTicket := newTicket("confirmed")
+		err := first.Client.SendTicketsStatus(ctx, confirmedTicket)
+		require.NoError(t, err)
+
+		assert.EventuallyWithT(t, func(t *assert.CollectT) {
+			assert.NotEmpty(t, scheduledFor(t, rdb, first.Namespace, confirmedTicket.ID))
+		}, 10*time.Second, 100*time.Millisecond)
+		assert.Empty(t, scheduledFor(t, rdb, second.Namespace, confirmedTicket.ID))
+	})
+
+	// The services are stopped and their keys removed once the subtest finishes.
+	for _, namespace := range namespaces {
+		var keys []string
+		iter := rdb.Scan(context.Background(), 0, namespace+".*", 0).Iterator()
+		for iter.Next(context.Background()) {
+			keys = append(keys, iter.Val())
+		}
+		require.NoError(t, iter.Err())
+		assert.Empty(t, keys, "keys of namespace %s not removed", namespace)
+	}
+}
+
+// scheduledFor returns the messages about the ticket scheduled in the namespace.
+func scheduledFor(t assert.TestingT, rdb *redis.Client, namespace string, ticketID string) []string {
+	members, err := rdb.ZRange(context.Background(), namespace+".scheduled-messages", 0, -1).Result()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	var scheduled []string
+	for _, member := range members {
+		var msg struct {
+			Payload []byte `json:"payload"`
+		}
+		_ = json.Unmarshal([]byte(member), &msg)
+		if bytes.Contains(msg.Payload, []byte(ticketID)) {
+			scheduled = append(scheduled, member)
+		}
+	}
+
+	return scheduled
+}